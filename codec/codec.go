@@ -0,0 +1,142 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Codec is encode and decode the body of a content type
+type Codec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// the built-in codecs
+var (
+	JSON    Codec = jsonCodec{}
+	XML     Codec = xmlCodec{}
+	YAML    Codec = yamlCodec{}
+	Form    Codec = formCodec{}
+	MsgPack Codec = msgpackCodec{}
+)
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Codec{
+		"application/json":                  JSON,
+		"text/json":                         JSON,
+		"application/xml":                   XML,
+		"text/xml":                          XML,
+		"application/yaml":                  YAML,
+		"application/x-yaml":                YAML,
+		"text/yaml":                         YAML,
+		"text/x-yaml":                       YAML,
+		"application/x-www-form-urlencoded": Form,
+		"application/msgpack":               MsgPack,
+		"application/x-msgpack":             MsgPack,
+		"application/vnd.msgpack":           MsgPack,
+	}
+)
+
+// Register is use the codec for the content types , the ContentType of codec is used if contentTypes is empty ,
+// the registered codec replace the old one
+func Register(c Codec, contentTypes ...string) {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{c.ContentType()}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, ct := range contentTypes {
+		registry[mediaType(ct)] = c
+	}
+}
+
+// Unregister is remove the codecs of the content types , the builtin codecs can be removed too
+func Unregister(contentTypes ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, ct := range contentTypes {
+		delete(registry, mediaType(ct))
+	}
+}
+
+// Lookup is the codec of content type , the parameters like charset are ignored ,
+// the structured syntax suffix is used if there is no codec of the type , eg: application/problem+json is JSON
+func Lookup(contentType string) (Codec, bool) {
+	mt := mediaType(contentType)
+	mu.RLock()
+	defer mu.RUnlock()
+	if c, ok := registry[mt]; ok {
+		return c, true
+	}
+	if i := strings.LastIndexByte(mt, '+'); i >= 0 {
+		c, ok := registry["application/"+mt[i+1:]]
+		return c, ok
+	}
+	return nil, false
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mt))
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+// Marshal is encoding/json without html escaping
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode append a newline
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string {
+	return "application/xml"
+}
+
+func (xmlCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlCodec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v)
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) ContentType() string {
+	return "application/yaml"
+}
+
+func (yamlCodec) Marshal(v any) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+func (yamlCodec) Unmarshal(data []byte, v any) error {
+	return yaml.Unmarshal(data, v)
+}
@@ -0,0 +1,704 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// msgpackCodec is MessagePack , see https://github.com/msgpack/msgpack/blob/master/spec.md ,
+// struct is a map of field names by msgpack tag , time.Time is the timestamp extension ,
+// decode into interface{} give nil , bool , int64 , uint64 , float64 , string , []byte , []any ,
+// map[string]any (map[any]any if a key is not string) and time.Time
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	e := &msgpackEncoder{}
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("msgpack: unmarshal into non-pointer %T", v)
+	}
+	d := &msgpackDecoder{data: data}
+	value, err := d.decode()
+	if err != nil {
+		return err
+	}
+	return assign(rv.Elem(), value)
+}
+
+const msgpackTimestampExt = -1
+
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) write(b ...byte) {
+	e.buf = append(e.buf, b...)
+}
+
+func (e *msgpackEncoder) write16(code byte, n uint16) {
+	e.write(code, byte(n>>8), byte(n))
+}
+
+func (e *msgpackEncoder) write32(code byte, n uint32) {
+	e.write(code, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func (e *msgpackEncoder) write64(code byte, n uint64) {
+	e.write(code)
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	e.write(b[:]...)
+}
+
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.write(0xc0)
+		return nil
+	}
+	if v.Type() == timeType {
+		e.encodeTime(v.Interface().(time.Time))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.write(0xc0)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			e.write(0xc3)
+		} else {
+			e.write(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.encodeUint(v.Uint())
+	case reflect.Float32:
+		e.write32(0xca, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.write64(0xcb, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.encodeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.write(0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.encodeBytes(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			e.encodeBytes(b)
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.write(0xc0)
+			return nil
+		}
+		return e.encodeMap(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) encodeInt(i int64) {
+	switch {
+	case i >= 0:
+		e.encodeUint(uint64(i))
+	case i >= -32:
+		e.write(byte(int8(i)))
+	case i >= math.MinInt8:
+		e.write(0xd0, byte(int8(i)))
+	case i >= math.MinInt16:
+		e.write16(0xd1, uint16(int16(i)))
+	case i >= math.MinInt32:
+		e.write32(0xd2, uint32(int32(i)))
+	default:
+		e.write64(0xd3, uint64(i))
+	}
+}
+
+func (e *msgpackEncoder) encodeUint(u uint64) {
+	switch {
+	case u < 128:
+		e.write(byte(u))
+	case u <= math.MaxUint8:
+		e.write(0xcc, byte(u))
+	case u <= math.MaxUint16:
+		e.write16(0xcd, uint16(u))
+	case u <= math.MaxUint32:
+		e.write32(0xce, uint32(u))
+	default:
+		e.write64(0xcf, u)
+	}
+}
+
+func (e *msgpackEncoder) encodeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.write(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		e.write(0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.write16(0xda, uint16(n))
+	default:
+		e.write32(0xdb, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) encodeBytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		e.write(0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.write16(0xc5, uint16(n))
+	default:
+		e.write32(0xc6, uint32(n))
+	}
+	e.buf = append(e.buf, b...)
+}
+
+func (e *msgpackEncoder) arrayHeader(n int) {
+	switch {
+	case n < 16:
+		e.write(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		e.write16(0xdc, uint16(n))
+	default:
+		e.write32(0xdd, uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) mapHeader(n int) {
+	switch {
+	case n < 16:
+		e.write(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		e.write16(0xde, uint16(n))
+	default:
+		e.write32(0xdf, uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) encodeArray(v reflect.Value) error {
+	e.arrayHeader(v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMap is sort the keys , so the output is stable
+func (e *msgpackEncoder) encodeMap(v reflect.Value) error {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	e.mapHeader(len(keys))
+	for _, k := range keys {
+		if err := e.encode(k); err != nil {
+			return err
+		}
+		if err := e.encode(v.MapIndex(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) encodeStruct(v reflect.Value) error {
+	type entry struct {
+		name  string
+		value reflect.Value
+	}
+	var entries []entry
+	for _, f := range structFields(v.Type(), "msgpack") {
+		fv, err := v.FieldByIndexErr(f.index)
+		if err != nil {
+			continue
+		}
+		if f.omitempty && fv.IsZero() {
+			continue
+		}
+		entries = append(entries, entry{f.name, fv})
+	}
+	e.mapHeader(len(entries))
+	for _, en := range entries {
+		e.encodeString(en.name)
+		if err := e.encode(en.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeTime is timestamp 32 if there is no nanoseconds , otherwise timestamp 96
+func (e *msgpackEncoder) encodeTime(t time.Time) {
+	sec, nsec := t.Unix(), t.Nanosecond()
+	if nsec == 0 && sec >= 0 && sec <= math.MaxUint32 {
+		e.write(0xd6)
+		e.write32(0xff, uint32(sec))
+		return
+	}
+	e.write(0xc7, 12)
+	e.write32(0xff, uint32(nsec))
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(sec))
+	e.write(b[:]...)
+}
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+// msgpackMaxDepth is the max nesting of arrays and maps , same as encoding/json ,
+// the decoder is recursive so the untrusted data can not overflow the stack
+const msgpackMaxDepth = 10000
+
+var errMsgpackDepth = fmt.Errorf("msgpack: exceeded max depth %d", msgpackMaxDepth)
+
+type msgpackDecoder struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// msgpackMap is the decoded map which keep the order of keys
+type msgpackMap struct {
+	keys   []any
+	values []any
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errMsgpackShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) readUint(n int) (uint64, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (d *msgpackDecoder) decode() (any, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	code := b[0]
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xf0 == 0x80:
+		return d.decodeMap(int(code & 0x0f))
+	case code&0xf0 == 0x90:
+		return d.decodeArray(int(code & 0x0f))
+	case code&0xe0 == 0xa0:
+		return d.decodeString(int(code & 0x1f))
+	}
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readUint(1 << (code - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readUint(1 << (code - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(int(n))
+	case 0xca:
+		u, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.readUint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.readUint(1 << (code - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return u, nil
+	case 0xd0:
+		u, err := d.readUint(1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := d.readUint(2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := d.readUint(4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := d.readUint(8)
+		return int64(u), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (code - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readUint(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(n))
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(n))
+	}
+	return nil, fmt.Errorf("msgpack: invalid code 0x%02x", code)
+}
+
+func (d *msgpackDecoder) decodeString(n int) (any, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// enter is count the nesting of array and map , call leave after decoded
+func (d *msgpackDecoder) enter() error {
+	d.depth++
+	if d.depth > msgpackMaxDepth {
+		return errMsgpackDepth
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) leave() {
+	d.depth--
+}
+
+func (d *msgpackDecoder) decodeArray(n int) (any, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackShort
+	}
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+	arr := make([]any, n)
+	for i := range arr {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = v
+	}
+	return arr, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n int) (any, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackShort
+	}
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+	m := &msgpackMap{keys: make([]any, n), values: make([]any, n)}
+	for i := 0; i < n; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		m.keys[i], m.values[i] = k, v
+	}
+	return m, nil
+}
+
+// decodeExt is only support the timestamp extension
+func (d *msgpackDecoder) decodeExt(n int) (any, error) {
+	b, err := d.read(n + 1)
+	if err != nil {
+		return nil, err
+	}
+	typ, data := int8(b[0]), b[1:]
+	if typ != msgpackTimestampExt {
+		return nil, fmt.Errorf("msgpack: unsupported extension type %d", typ)
+	}
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(v&0x3ffffffff), int64(v>>34)), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data[:4])
+		sec := int64(binary.BigEndian.Uint64(data[4:]))
+		return time.Unix(sec, int64(nsec)), nil
+	}
+	return nil, fmt.Errorf("msgpack: invalid timestamp length %d", len(data))
+}
+
+// toInterface is the decoded value for interface{} , msgpackMap become map
+func toInterface(v any) any {
+	switch x := v.(type) {
+	case []any:
+		for i := range x {
+			x[i] = toInterface(x[i])
+		}
+		return x
+	case *msgpackMap:
+		stringKeys := true
+		for _, k := range x.keys {
+			if _, ok := k.(string); !ok {
+				stringKeys = false
+				break
+			}
+		}
+		if stringKeys {
+			m := make(map[string]any, len(x.keys))
+			for i, k := range x.keys {
+				m[k.(string)] = toInterface(x.values[i])
+			}
+			return m
+		}
+		m := make(map[any]any, len(x.keys))
+		for i, k := range x.keys {
+			if b, ok := k.([]byte); ok {
+				k = string(b)
+			}
+			if _, ok := k.(*msgpackMap); ok {
+				k = fmt.Sprint(toInterface(k))
+			}
+			if _, ok := k.([]any); ok {
+				k = fmt.Sprint(toInterface(k))
+			}
+			m[k] = toInterface(x.values[i])
+		}
+		return m
+	}
+	return v
+}
+
+// assign is set the decoded value to rv
+func assign(rv reflect.Value, v any) error {
+	if v == nil {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return assign(rv.Elem(), v)
+	}
+	if rv.Kind() == reflect.Interface {
+		iv := reflect.ValueOf(toInterface(v))
+		if !iv.Type().AssignableTo(rv.Type()) {
+			return fmt.Errorf("msgpack: can not decode %T into %s", v, rv.Type())
+		}
+		rv.Set(iv)
+		return nil
+	}
+
+	mismatch := fmt.Errorf("msgpack: can not decode %T into %s", v, rv.Type())
+	switch x := v.(type) {
+	case bool:
+		if rv.Kind() != reflect.Bool {
+			return mismatch
+		}
+		rv.SetBool(x)
+	case int64:
+		return assignNumber(rv, float64(x), x, uint64(x), x >= 0, mismatch)
+	case uint64:
+		return assignNumber(rv, float64(x), int64(x), x, true, mismatch)
+	case float64:
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			rv.SetFloat(x)
+		default:
+			return mismatch
+		}
+	case string:
+		switch {
+		case rv.Kind() == reflect.String:
+			rv.SetString(x)
+		case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+			rv.SetBytes([]byte(x))
+		default:
+			return mismatch
+		}
+	case []byte:
+		switch {
+		case rv.Kind() == reflect.String:
+			rv.SetString(string(x))
+		case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+			rv.SetBytes(x)
+		case rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8:
+			reflect.Copy(rv, reflect.ValueOf(x))
+		default:
+			return mismatch
+		}
+	case time.Time:
+		if rv.Type() != timeType {
+			return mismatch
+		}
+		rv.Set(reflect.ValueOf(x))
+	case []any:
+		switch rv.Kind() {
+		case reflect.Slice:
+			slice := reflect.MakeSlice(rv.Type(), len(x), len(x))
+			for i, item := range x {
+				if err := assign(slice.Index(i), item); err != nil {
+					return err
+				}
+			}
+			rv.Set(slice)
+		case reflect.Array:
+			for i := 0; i < rv.Len() && i < len(x); i++ {
+				if err := assign(rv.Index(i), x[i]); err != nil {
+					return err
+				}
+			}
+		default:
+			return mismatch
+		}
+	case *msgpackMap:
+		switch rv.Kind() {
+		case reflect.Map:
+			if rv.IsNil() {
+				rv.Set(reflect.MakeMapWithSize(rv.Type(), len(x.keys)))
+			}
+			for i, k := range x.keys {
+				key := reflect.New(rv.Type().Key()).Elem()
+				if err := assign(key, k); err != nil {
+					return err
+				}
+				value := reflect.New(rv.Type().Elem()).Elem()
+				if err := assign(value, x.values[i]); err != nil {
+					return err
+				}
+				rv.SetMapIndex(key, value)
+			}
+		case reflect.Struct:
+			return assignStruct(rv, x)
+		default:
+			return mismatch
+		}
+	default:
+		return mismatch
+	}
+	return nil
+}
+
+func assignNumber(rv reflect.Value, f float64, i int64, u uint64, positive bool, mismatch error) error {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if (positive && u > math.MaxInt64) || rv.OverflowInt(i) {
+			return fmt.Errorf("msgpack: %v overflow %s", f, rv.Type())
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !positive || rv.OverflowUint(u) {
+			return fmt.Errorf("msgpack: %v overflow %s", f, rv.Type())
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		rv.SetFloat(f)
+	default:
+		return mismatch
+	}
+	return nil
+}
+
+func assignStruct(rv reflect.Value, m *msgpackMap) error {
+	fields := make(map[string][]int)
+	for _, f := range structFields(rv.Type(), "msgpack") {
+		fields[f.name] = f.index
+	}
+	for i, k := range m.keys {
+		name, ok := k.(string)
+		if !ok {
+			continue
+		}
+		index, ok := fields[name]
+		if !ok {
+			continue
+		}
+		fv, err := fieldByIndexAlloc(rv, index)
+		if err != nil {
+			return err
+		}
+		if err := assign(fv, m.values[i]); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package codec
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// field is a field of struct and its name in tag
+type field struct {
+	index     []int
+	name      string
+	omitempty bool
+}
+
+// structFields is the fields of struct by tag , eg: `query:"name,omitempty"` ,
+// the field without tag use the field name , "-" is ignored , the embedded struct without tag is flattened
+func structFields(t reflect.Type, tag string) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		value, hasTag := sf.Tag.Lookup(tag)
+		if value == "-" {
+			continue
+		}
+		name, opt, _ := strings.Cut(value, ",")
+		if sf.Anonymous && !hasTag {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				for _, f := range structFields(ft, tag) {
+					f.index = append([]int{i}, f.index...)
+					fields = append(fields, f)
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{index: []int{i}, name: name, omitempty: opt == "omitempty"})
+	}
+	return fields
+}
+
+// EncodeValues is encode the struct to url.Values by tag , see structFields ,
+// omitempty skip the zero value , slice and array is many values of a key ,
+// time.Time is RFC3339 , nil pointer is skipped
+func EncodeValues(v any, tag string) (url.Values, error) {
+	values := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T is not a struct", v)
+	}
+	for _, f := range structFields(rv.Type(), tag) {
+		fv, err := rv.FieldByIndexErr(f.index)
+		if err != nil {
+			// nil embedded pointer
+			continue
+		}
+		if f.omitempty && fv.IsZero() {
+			continue
+		}
+		if err := encodeValue(values, f.name, fv); err != nil {
+			return nil, fmt.Errorf("field %s: %v", f.name, err)
+		}
+	}
+	return values, nil
+}
+
+func encodeValue(values url.Values, name string, fv reflect.Value) error {
+	for fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	if fv.Type() == timeType {
+		values.Add(name, fv.Interface().(time.Time).Format(time.RFC3339))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Array:
+		// []byte is a string
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8 {
+			values.Add(name, string(fv.Bytes()))
+			return nil
+		}
+		for i := 0; i < fv.Len(); i++ {
+			if err := encodeValue(values, name, fv.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map, reflect.Struct, reflect.Func, reflect.Chan:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	s, err := cast.ToStringE(fv.Interface())
+	if err != nil {
+		return err
+	}
+	values.Add(name, s)
+	return nil
+}
+
+// DecodeValues is decode url.Values into the struct pointer by tag , see EncodeValues
+func DecodeValues(values url.Values, v any, tag string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("decode values into non-pointer %T", v)
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("decode values into %T , must be a struct pointer", v)
+	}
+	for _, f := range structFields(rv.Type(), tag) {
+		vals, ok := values[f.name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		fv, err := fieldByIndexAlloc(rv, f.index)
+		if err != nil {
+			return err
+		}
+		if err := decodeValue(fv, vals); err != nil {
+			return fmt.Errorf("field %s: %v", f.name, err)
+		}
+	}
+	return nil
+}
+
+// fieldByIndexAlloc is FieldByIndex which allocate the nil embedded pointers
+func fieldByIndexAlloc(rv reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return rv, fmt.Errorf("can not set embedded pointer %s", rv.Type())
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, nil
+}
+
+func decodeValue(fv reflect.Value, vals []string) error {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return decodeValue(fv.Elem(), vals)
+	}
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+		for i, s := range vals {
+			if err := decodeValue(slice.Index(i), []string{s}); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setString(fv, vals[0])
+}
+
+func setString(fv reflect.Value, s string) error {
+	if fv.Type() == timeType {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		// []byte
+		fv.SetBytes([]byte(s))
+	case reflect.Interface:
+		if fv.NumMethod() != 0 {
+			return fmt.Errorf("unsupported type %s", fv.Type())
+		}
+		fv.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+type formCodec struct{}
+
+func (formCodec) ContentType() string {
+	return "application/x-www-form-urlencoded"
+}
+
+// Marshal is encode url.Values , map[string]string , map[string][]string , map[string]any ,
+// or struct by form tag
+func (formCodec) Marshal(v any) ([]byte, error) {
+	var values url.Values
+	switch m := v.(type) {
+	case url.Values:
+		values = m
+	case map[string][]string:
+		values = m
+	case map[string]string:
+		values = url.Values{}
+		for k, v := range m {
+			values.Set(k, v)
+		}
+	case map[string]any:
+		values = url.Values{}
+		for k, v := range m {
+			s, err := cast.ToStringE(v)
+			if err != nil {
+				return nil, fmt.Errorf("form value of %s: %v", k, err)
+			}
+			values.Set(k, s)
+		}
+	default:
+		var err error
+		if values, err = EncodeValues(v, "form"); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(values.Encode()), nil
+}
+
+// Unmarshal is decode into *url.Values , *map[string][]string , *map[string]string ,
+// or struct pointer by form tag
+func (formCodec) Unmarshal(data []byte, v any) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	switch m := v.(type) {
+	case *url.Values:
+		*m = values
+	case *map[string][]string:
+		*m = values
+	case *map[string]string:
+		*m = make(map[string]string, len(values))
+		for k := range values {
+			(*m)[k] = values.Get(k)
+		}
+	default:
+		return DecodeValues(values, v, "form")
+	}
+	return nil
+}
@@ -15,6 +15,10 @@ const (
 	DefaultTimeout                     = 60 * time.Second
 	DefaultTLSConfigInsecureSkipVerify = true
 	PiplineCtxValueKey                 = "values"
+	DefaultErrorBodySize               = 512 // max body size in StatusError
+
+	DefaultSSERetry       = 3 * time.Second // reconnection delay of SSE if the server does not set retry
+	DefaultSSEMaxLineSize = 1 << 20         // max line size of SSE stream
 )
 
 func SetDefaultDebug() bool {
@@ -4,8 +4,22 @@ import "errors"
 
 type StatusCodeErrorType error
 type JsonKeyErrorType error
+type ReplayBodyErrorType error
+type CircuitOpenErrorType error
+type PathParamErrorType error
+type QueryEncodeErrorType error
+type BodyTypeErrorType error
+type ChecksumErrorType error
+type EventStreamErrorType error
 
 var (
-	StatusCodeError = StatusCodeErrorType(errors.New("StatusCodeError"))
-	JsonKeyError    = JsonKeyErrorType(errors.New("JsonKeyError"))
+	StatusCodeError  = StatusCodeErrorType(errors.New("StatusCodeError"))
+	JsonKeyError     = JsonKeyErrorType(errors.New("JsonKeyError"))
+	ReplayBodyError  = ReplayBodyErrorType(errors.New("ReplayBodyError"))
+	CircuitOpenError = CircuitOpenErrorType(errors.New("CircuitOpenError"))
+	PathParamError   = PathParamErrorType(errors.New("PathParamError"))
+	QueryEncodeError = QueryEncodeErrorType(errors.New("QueryEncodeError"))
+	BodyTypeError    = BodyTypeErrorType(errors.New("BodyTypeError"))
+	ChecksumError    = ChecksumErrorType(errors.New("ChecksumError"))
+	EventStreamError = EventStreamErrorType(errors.New("EventStreamError"))
 )
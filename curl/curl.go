@@ -1,10 +1,9 @@
 package curl
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"time"
 
@@ -17,75 +16,126 @@ import (
 // GET is ShortCut get http method but not reuse tpc connect
 // The first args[0] is query , args[1] is header
 func GET(url string, args ...map[string]string) response.Response {
-	client := client.NewClient(client.WithDefault())
-	return client.Req(http.MethodGet, url, nil, args...)
+	return GETCtx(context.Background(), url, args...)
+}
 
+// GETCtx is GET with context
+func GETCtx(ctx context.Context, url string, args ...map[string]string) response.Response {
+	client := client.NewClient(client.WithDefault())
+	return client.ReqCtx(ctx, http.MethodGet, url, nil, args...)
 }
 
 // GETRaw is response body not close
 func GETRaw(url string, args ...map[string]string) response.Response {
-	client := client.NewClient(client.WithDefault())
-	return client.ReqRaw(http.MethodGet, url, nil, args...)
+	return GETRawCtx(context.Background(), url, args...)
+}
 
+// GETRawCtx is GETRaw with context
+func GETRawCtx(ctx context.Context, url string, args ...map[string]string) response.Response {
+	client := client.NewClient(client.WithDefault())
+	return client.ReqRawCtx(ctx, http.MethodGet, url, nil, args...)
 }
 
 // POST is shortcut post method with json
 func POST(url string, postbody any, args ...map[string]string) response.Response {
-	client := client.NewClient(client.WithDefault())
-	return client.Req(http.MethodPost, url, postbody, args...)
+	return POSTCtx(context.Background(), url, postbody, args...)
+}
 
+// POSTCtx is POST with context
+func POSTCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
+	client := client.NewClient(client.WithDefault())
+	return client.ReqCtx(ctx, http.MethodPost, url, postbody, args...)
 }
 
 // POSTRaw is response body not close
 func POSTRaw(url string, postbody any, args ...map[string]string) response.Response {
-	client := client.NewClient(client.WithDefault())
-	return client.ReqRaw(http.MethodPost, url, postbody, args...)
+	return POSTRawCtx(context.Background(), url, postbody, args...)
+}
 
+// POSTRawCtx is POSTRaw with context
+func POSTRawCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
+	client := client.NewClient(client.WithDefault())
+	return client.ReqRawCtx(ctx, http.MethodPost, url, postbody, args...)
 }
 
 // PUT is shortcut post method with json
 func PUT(url string, postbody any, args ...map[string]string) response.Response {
+	return PUTCtx(context.Background(), url, postbody, args...)
+}
+
+// PUTCtx is PUT with context
+func PUTCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
 	client := client.NewClient(client.WithDefault())
-	return client.Req(http.MethodPut, url, postbody, args...)
+	return client.ReqCtx(ctx, http.MethodPut, url, postbody, args...)
 }
 
 // PUTRaw is response body not close
 func PUTRaw(url string, postbody any, args ...map[string]string) response.Response {
+	return PUTRawCtx(context.Background(), url, postbody, args...)
+}
+
+// PUTRawCtx is PUTRaw with context
+func PUTRawCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
 	client := client.NewClient(client.WithDefault())
-	return client.ReqRaw(http.MethodPut, url, postbody, args...)
+	return client.ReqRawCtx(ctx, http.MethodPut, url, postbody, args...)
 }
 
 // Patch is shortcut post method with json
 func PATCH(url string, postbody any, args ...map[string]string) response.Response {
+	return PATCHCtx(context.Background(), url, postbody, args...)
+}
+
+// PATCHCtx is PATCH with context
+func PATCHCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
 	client := client.NewClient(client.WithDefault())
-	return client.Req(http.MethodPatch, url, postbody, args...)
+	return client.ReqCtx(ctx, http.MethodPatch, url, postbody, args...)
 }
 
 // PATCHRaw is response body not close
 func PATCHRaw(url string, postbody any, args ...map[string]string) response.Response {
+	return PATCHRawCtx(context.Background(), url, postbody, args...)
+}
+
+// PATCHRawCtx is PATCHRaw with context
+func PATCHRawCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
 	client := client.NewClient(client.WithDefault())
-	return client.ReqRaw(http.MethodPatch, url, postbody, args...)
+	return client.ReqRawCtx(ctx, http.MethodPatch, url, postbody, args...)
 }
 
 // Delete is shortcut post method with json
 func DELETE(url string, postbody any, args ...map[string]string) response.Response {
+	return DELETECtx(context.Background(), url, postbody, args...)
+}
+
+// DELETECtx is DELETE with context
+func DELETECtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
 	client := client.NewClient(client.WithDefault())
-	return client.Req(http.MethodDelete, url, postbody, args...)
+	return client.ReqCtx(ctx, http.MethodDelete, url, postbody, args...)
 }
 
 // DELETERaw is response body not close
 func DELETERaw(url string, postbody any, args ...map[string]string) response.Response {
+	return DELETERawCtx(context.Background(), url, postbody, args...)
+}
+
+// DELETERawCtx is DELETERaw with context
+func DELETERawCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
 	client := client.NewClient(client.WithDefault())
-	return client.ReqRaw(http.MethodDelete, url, postbody, args...)
+	return client.ReqRawCtx(ctx, http.MethodDelete, url, postbody, args...)
 }
 
 // GETBind is bind struct with Get method
 func GETBind(v any, url string, args ...map[string]string) error {
-	resp := GETRaw(url, args...)
+	return GETBindCtx(context.Background(), v, url, args...)
+}
+
+// GETBindCtx is GETBind with context
+func GETBindCtx(ctx context.Context, v any, url string, args ...map[string]string) error {
+	resp := GETRawCtx(ctx, url, args...)
 	if !resp.OK() && resp.GetError() != nil {
 		return resp.GetError()
 	}
-	if err := resp.GetStruct(&v); err != nil {
+	if err := resp.GetStruct(v); err != nil {
 		return err
 	}
 	return nil
@@ -94,11 +144,16 @@ func GETBind(v any, url string, args ...map[string]string) error {
 
 // POSTBind is bind struct with Get method
 func POSTBind(v any, url string, postbody any, args ...map[string]string) error {
-	resp := POSTRaw(url, postbody, args...)
+	return POSTBindCtx(context.Background(), v, url, postbody, args...)
+}
+
+// POSTBindCtx is POSTBind with context
+func POSTBindCtx(ctx context.Context, v any, url string, postbody any, args ...map[string]string) error {
+	resp := POSTRawCtx(ctx, url, postbody, args...)
 	if !resp.OK() && resp.GetError() != nil {
 		return resp.GetError()
 	}
-	if err := resp.GetStruct(&v); err != nil {
+	if err := resp.GetStruct(v); err != nil {
 		return err
 	}
 	return nil
@@ -106,17 +161,27 @@ func POSTBind(v any, url string, postbody any, args ...map[string]string) error
 
 // POSTFormBind is bind struct with Get method
 func POSTFormBind(v any, url string, postbody any, args ...map[string]string) error {
-	resp := POSTForm(url, postbody, args...)
+	return POSTFormBindCtx(context.Background(), v, url, postbody, args...)
+}
+
+// POSTFormBindCtx is POSTFormBind with context
+func POSTFormBindCtx(ctx context.Context, v any, url string, postbody any, args ...map[string]string) error {
+	resp := POSTFormCtx(ctx, url, postbody, args...)
 	if !resp.OK() && resp.GetError() != nil {
 		return resp.GetError()
 	}
-	if err := resp.GetStruct(&v); err != nil {
+	if err := resp.GetStruct(v); err != nil {
 		return err
 	}
 	return nil
 }
 
 func POSTForm(url string, postbody any, args ...map[string]string) response.Response {
+	return POSTFormCtx(context.Background(), url, postbody, args...)
+}
+
+// POSTFormCtx is POSTForm with context
+func POSTFormCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
 	query, header := request.Getqueryheader(args...)
 	r, err := request.NewReuqest(
 		http.MethodPost,
@@ -125,6 +190,7 @@ func POSTForm(url string, postbody any, args ...map[string]string) response.Resp
 		request.WithQuery(query),
 		request.WithHeader(header),
 		request.WithContentType(config.FormContectType),
+		request.WithContext(ctx),
 	)
 	if err != nil {
 		return response.Response{Resp: nil, Body: nil, Err: err}
@@ -145,14 +211,21 @@ func POSTForm(url string, postbody any, args ...map[string]string) response.Resp
 
 // POSTBinaryBody is binary body upload
 func POSTBinaryBody(url string, binfile io.Reader, timeout time.Duration, args ...map[string]string) response.Response {
+	return POSTBinaryBodyCtx(context.Background(), url, binfile, timeout, args...)
+}
+
+// POSTBinaryBodyCtx is POSTBinaryBody with context
+func POSTBinaryBodyCtx(ctx context.Context, url string, binfile io.Reader, timeout time.Duration, args ...map[string]string) response.Response {
 	query, header := request.Getqueryheader(args...)
 
 	r, err := request.NewReuqest(
 		http.MethodPost,
 		url,
+		request.WithBody(binfile),
 		request.WithContentType("binary/octet-stream"),
 		request.WithQuery(query),
 		request.WithHeader(header),
+		request.WithContext(ctx),
 	)
 	if err != nil {
 		return response.Response{Resp: nil, Body: nil, Err: err}
@@ -174,40 +247,33 @@ func POSTBinaryBody(url string, binfile io.Reader, timeout time.Duration, args .
 
 // POSTMultiPartUpload is upload file , files key is fieldname of file ,file name is in fields key
 func POSTMultiPartUpload(url string, files map[string]io.Reader, fields map[string]string, timeout time.Duration, args ...map[string]string) response.Response {
-	buf := &bytes.Buffer{}
-	writer := multipart.NewWriter(buf)
+	return POSTMultiPartUploadCtx(context.Background(), url, files, fields, timeout, args...)
+}
+
+// POSTMultiPartUploadCtx is POSTMultiPartUpload with context , the files are streamed without buffering
+func POSTMultiPartUploadCtx(ctx context.Context, url string, files map[string]io.Reader, fields map[string]string, timeout time.Duration, args ...map[string]string) response.Response {
+	m := request.NewMultipart()
 	for name, file := range files {
 		filename, ok := fields[name]
 		if !ok {
-			return response.Response{Resp: nil, Body: nil, Err: errors.New(name + "is not found in the fields")}
+			return response.Response{Resp: nil, Body: nil, Err: errors.New(name + " is not found in the fields")}
 		}
-		writer, err := writer.CreateFormFile(name, filename)
-		if err != nil {
-			return response.Response{Resp: nil, Body: nil, Err: err}
-		}
-		_, err = io.Copy(writer, file)
-		if err != nil {
-			return response.Response{Resp: nil, Body: nil, Err: err}
-		}
-		delete(fields, name)
+		m.Reader(name, filename, file, -1)
 	}
 	for k, v := range fields {
-		err := writer.WriteField(k, v)
-		if err != nil {
-			return response.Response{Resp: nil, Body: nil, Err: err}
+		if _, ok := files[k]; ok {
+			continue
 		}
-	}
-	err := writer.Close()
-	if err != nil {
-		return response.Response{Resp: nil, Body: nil, Err: err}
+		m.Field(k, v)
 	}
 	query, header := request.Getqueryheader(args...)
 	r, err := request.NewReuqest(
 		http.MethodPost,
 		url,
-		request.WithContentType(writer.FormDataContentType()),
+		request.WithMultipart(m),
 		request.WithQuery(query),
 		request.WithHeader(header),
+		request.WithContext(ctx),
 	)
 	if err != nil {
 		return response.Response{Resp: nil, Body: nil, Err: err}
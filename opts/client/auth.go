@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/request"
+)
+
+// DefaultTokenExpiryDelta is refresh the oauth2 token before it is really expired
+var DefaultTokenExpiryDelta = 10 * time.Second
+
+// authenticator is set the Authorization header of request
+type authenticator interface {
+	authorize(client *Client, r *request.Request) error
+	// invalidate is drop the credential after a 401 response , return false if the credential can not be refreshed
+	invalidate(r *request.Request, resp *http.Response) bool
+}
+
+type AuthOption struct{ auth authenticator }
+
+func (a AuthOption) apply(opts *ClientOptions) {
+	opts.Auth = a.auth
+}
+
+// WithBasicAuth is set basic Authorization header to every request
+func WithBasicAuth(username string, password string) ClientOption {
+	return AuthOption{basicAuth{username: username, password: password}}
+}
+
+// WithBearerToken is set Bearer Authorization header to every request
+func WithBearerToken(token string) ClientOption {
+	return AuthOption{bearerToken(token)}
+}
+
+// WithOAuth2ClientCredentials is fetch the token from tokenURL by oauth2 client credentials grant ,
+// the token is cached and refreshed before expiry , a request get 401 will refresh the token and retry once ,
+// the token request is sent by the same client
+func WithOAuth2ClientCredentials(tokenURL string, clientID string, clientSecret string, scopes ...string) ClientOption {
+	return AuthOption{&clientCredentials{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+	}}
+}
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+func (b basicAuth) authorize(client *Client, r *request.Request) error {
+	r.HttpReq.SetBasicAuth(b.username, b.password)
+	return nil
+}
+
+func (b basicAuth) invalidate(r *request.Request, resp *http.Response) bool {
+	return false
+}
+
+type bearerToken string
+
+func (b bearerToken) authorize(client *Client, r *request.Request) error {
+	r.HttpReq.Header.Set("Authorization", "Bearer "+string(b))
+	return nil
+}
+
+func (b bearerToken) invalidate(r *request.Request, resp *http.Response) bool {
+	return false
+}
+
+// Token is the response of oauth2 token endpoint
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+type clientCredentials struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (c *clientCredentials) authorize(client *Client, r *request.Request) error {
+	token, err := c.getToken(client, r.HttpReq.Context())
+	if err != nil {
+		return err
+	}
+	r.HttpReq.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (c *clientCredentials) invalidate(r *request.Request, resp *http.Response) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// only drop the token used by this request , it may be refreshed by other request already
+	if r.HttpReq.Header.Get("Authorization") == "Bearer "+c.token {
+		c.token = ""
+	}
+	return true
+}
+
+func (c *clientCredentials) getToken(client *Client, ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && (c.expiry.IsZero() || time.Now().Before(c.expiry)) {
+		return c.token, nil
+	}
+
+	form := map[string]string{"grant_type": "client_credentials"}
+	if len(c.scopes) > 0 {
+		form["scope"] = strings.Join(c.scopes, " ")
+	}
+	// client_id and client_secret is form urlencoded before basic auth , see rfc6749 2.3.1
+	credentials := url.QueryEscape(c.clientID) + ":" + url.QueryEscape(c.clientSecret)
+	header := map[string]string{
+		"Accept":        config.JsonContectType,
+		"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials)),
+	}
+	resp := client.Send(
+		ctx,
+		http.MethodPost,
+		c.tokenURL,
+		request.WithBody(form),
+		request.WithContentType(config.FormContectType),
+		request.WithHeader(header),
+	)
+	if err := resp.Raise(); err != nil {
+		return "", fmt.Errorf("fetch oauth2 token: %w", err)
+	}
+	var token Token
+	if err := json.Unmarshal(resp.Body, &token); err != nil {
+		return "", fmt.Errorf("fetch oauth2 token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("fetch oauth2 token: access_token is empty")
+	}
+	c.token = token.AccessToken
+	c.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		expiresIn := time.Duration(token.ExpiresIn) * time.Second
+		delta := DefaultTokenExpiryDelta
+		if delta > expiresIn/2 {
+			delta = expiresIn / 2
+		}
+		c.expiry = time.Now().Add(expiresIn - delta)
+	}
+	return c.token, nil
+}
+
+// authMiddleware is authorize the request , refresh the credential and retry once if get 401
+func authMiddleware(client *Client, auth authenticator) Middleware {
+	return func(next Handler) Handler {
+		return func(r *request.Request) (*http.Response, error) {
+			ctx := r.HttpReq.Context()
+			// the caller set Authorization header by itself , include the oauth2 token request
+			if r.HttpReq.Header.Get("Authorization") != "" {
+				return next(r)
+			}
+			// authorize a copy , so the retry of outer middleware can authorize again
+			authed := &request.Request{Opts: r.Opts, HttpReq: r.HttpReq.Clone(ctx)}
+			if err := auth.authorize(client, authed); err != nil {
+				return nil, err
+			}
+			resp, err := next(authed)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || !auth.invalidate(authed, resp) {
+				return resp, err
+			}
+			if rerr := r.Rewind(); rerr != nil {
+				return resp, err
+			}
+			discard(resp)
+			authed = &request.Request{Opts: r.Opts, HttpReq: r.HttpReq.Clone(ctx)}
+			if err := auth.authorize(client, authed); err != nil {
+				return nil, err
+			}
+			return next(authed)
+		}
+	}
+}
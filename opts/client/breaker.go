@@ -0,0 +1,241 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/request"
+)
+
+const (
+	DefaultCircuitFailureRatio   = 0.5
+	DefaultCircuitMinRequests    = 10
+	DefaultCircuitWindow         = 10 * time.Second
+	DefaultCircuitOpenDuration   = 30 * time.Second
+	DefaultCircuitHalfOpenProbes = 1
+)
+
+// CircuitState is the state of a host breaker
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // requests are sent and counted
+	CircuitOpen                         // requests fail fast with ErrCircuitOpen
+	CircuitHalfOpen                     // a few probe requests are sent to check if the host is recovered
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerConfig is describe when the breaker of a host is opened and closed
+// the zero value of each field use the default value
+type CircuitBreakerConfig struct {
+	FailureRatio   float64       // open the breaker if failures/requests of the window reach it
+	MinRequests    int           // do not open the breaker if the window has less requests
+	Window         time.Duration // the counts of closed breaker are reset every window
+	OpenDuration   time.Duration // how long the breaker keep open before half-open
+	HalfOpenProbes int           // probe requests of half-open , the breaker is closed if all of them succeed
+	// IsFailure is whether the result is a failure , default is network error or 5xx status code ,
+	// the request canceled by caller is never counted
+	IsFailure func(resp *http.Response, err error) bool
+	// OnStateChange is called after the breaker of host changed state , eg: alert
+	OnStateChange func(host string, from CircuitState, to CircuitState)
+}
+
+// ErrCircuitOpen is returned without sending the request when the breaker of host is open ,
+// errors.Is(err, config.CircuitOpenError) is true
+type ErrCircuitOpen struct {
+	Host  string
+	State CircuitState
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit breaker of %s is %s", e.Host, e.State)
+}
+
+func (e *ErrCircuitOpen) Unwrap() error {
+	return config.CircuitOpenError
+}
+
+type CircuitBreakerOption CircuitBreakerConfig
+
+func (c CircuitBreakerOption) apply(opts *ClientOptions) {
+	cfg := CircuitBreakerConfig(c)
+	if cfg.FailureRatio <= 0 || cfg.FailureRatio > 1 {
+		cfg.FailureRatio = DefaultCircuitFailureRatio
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = DefaultCircuitMinRequests
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultCircuitWindow
+	}
+	if cfg.OpenDuration <= 0 {
+		cfg.OpenDuration = DefaultCircuitOpenDuration
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = DefaultCircuitHalfOpenProbes
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = isFailure
+	}
+	opts.circuitBreaker = &circuitBreaker{cfg: cfg, hosts: make(map[string]*hostBreaker)}
+}
+
+// WithCircuitBreaker is track the failures of every host , fail fast with ErrCircuitOpen when a host is down ,
+// the breaker is inside retry , every attempt is counted and ErrCircuitOpen is not retried
+func WithCircuitBreaker(cfg ...CircuitBreakerConfig) ClientOption {
+	var c CircuitBreakerConfig
+	if len(cfg) > 0 {
+		c = cfg[0]
+	}
+	return CircuitBreakerOption(c)
+}
+
+func isFailure(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+type circuitBreaker struct {
+	cfg   CircuitBreakerConfig
+	mu    sync.Mutex
+	hosts map[string]*hostBreaker // host:port >> breaker
+}
+
+// hostBreaker is the state of a host , generation is increased on every transition ,
+// so the result of request which is allowed by a previous state is ignored
+type hostBreaker struct {
+	state       CircuitState
+	generation  uint64
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int // probe requests in flight or succeed of half-open
+	successes   int
+}
+
+type transition struct {
+	from CircuitState
+	to   CircuitState
+}
+
+func (b *hostBreaker) setState(state CircuitState, now time.Time) transition {
+	t := transition{from: b.state, to: state}
+	b.state = state
+	b.generation++
+	b.windowStart = now
+	b.requests, b.failures = 0, 0
+	b.probes, b.successes = 0, 0
+	if state == CircuitOpen {
+		b.openedAt = now
+	}
+	return t
+}
+
+// allow is whether the request can be sent , return the generation to record the result
+func (cb *circuitBreaker) allow(host string) (uint64, []transition, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	b, ok := cb.hosts[host]
+	if !ok {
+		b = &hostBreaker{windowStart: time.Now()}
+		cb.hosts[host] = b
+	}
+	now := time.Now()
+	var transitions []transition
+	switch b.state {
+	case CircuitClosed:
+		if now.Sub(b.windowStart) >= cb.cfg.Window {
+			b.windowStart = now
+			b.requests, b.failures = 0, 0
+		}
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < cb.cfg.OpenDuration {
+			return 0, nil, &ErrCircuitOpen{Host: host, State: CircuitOpen}
+		}
+		transitions = append(transitions, b.setState(CircuitHalfOpen, now))
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probes >= cb.cfg.HalfOpenProbes {
+			return 0, transitions, &ErrCircuitOpen{Host: host, State: CircuitHalfOpen}
+		}
+		b.probes++
+	}
+	return b.generation, transitions, nil
+}
+
+// record is count the result of request , counted is false if the request is canceled by caller
+func (cb *circuitBreaker) record(host string, generation uint64, counted bool, failed bool) []transition {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	b := cb.hosts[host]
+	if b.generation != generation {
+		return nil
+	}
+	now := time.Now()
+	switch b.state {
+	case CircuitClosed:
+		if !counted {
+			return nil
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= cb.cfg.MinRequests && float64(b.failures)/float64(b.requests) >= cb.cfg.FailureRatio {
+			return []transition{b.setState(CircuitOpen, now)}
+		}
+	case CircuitHalfOpen:
+		if !counted {
+			// give the probe back
+			b.probes--
+			return nil
+		}
+		if failed {
+			return []transition{b.setState(CircuitOpen, now)}
+		}
+		b.successes++
+		if b.successes >= cb.cfg.HalfOpenProbes {
+			return []transition{b.setState(CircuitClosed, now)}
+		}
+	}
+	return nil
+}
+
+// notify is call OnStateChange outside the lock , so the callback can use the client
+func (cb *circuitBreaker) notify(host string, transitions []transition) {
+	if cb.cfg.OnStateChange == nil {
+		return
+	}
+	for _, t := range transitions {
+		cb.cfg.OnStateChange(host, t.from, t.to)
+	}
+}
+
+func (cb *circuitBreaker) middleware(next Handler) Handler {
+	return func(r *request.Request) (*http.Response, error) {
+		host := canonicalHost(r.HttpReq)
+		generation, transitions, err := cb.allow(host)
+		cb.notify(host, transitions)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := next(r)
+		counted := err == nil || r.HttpReq.Context().Err() == nil
+		cb.notify(host, cb.record(host, generation, counted, counted && cb.cfg.IsFailure(resp, err)))
+		return resp, err
+	}
+}
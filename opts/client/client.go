@@ -2,15 +2,13 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"io"
 	"net/http"
-	"net/http/httptrace"
-	"os"
+	"net/url"
 	"time"
 
-	"github.com/davecgh/go-spew/spew"
-	"github.com/tidwall/gjson"
 	"github.com/zengzhengrong/request/config"
 	"github.com/zengzhengrong/request/request"
 	"github.com/zengzhengrong/request/response"
@@ -20,17 +18,43 @@ type DebugOption bool
 type TimeoutOption time.Duration
 type TransportOption struct{ http.RoundTripper }
 type CheckRedirectOption func(req *http.Request, via []*http.Request) error
+
+// Deprecated: TLSClientConfigOption is kept for compatibility , use WithTLSClientConfig
 type TLSClientConfigOption struct{ tls.Config }
+type TLSConfigOption struct{ *tls.Config }
 type Default struct{}
 type RespBodySizeOption int64
+type RaiseForStatusOption bool
+type SignerOption struct{ request.Signer }
 
 type ClientOptions struct {
 	Debug           bool
 	Timeout         time.Duration
 	Transport       http.RoundTripper
 	CheckRedirect   func(req *http.Request, via []*http.Request) error
-	TLSClientConfig TLSClientConfigOption
+	TLSClientConfig TLSClientConfigOption // Deprecated: it is not set by options , use TLSConfig
+	TLSConfig       *tls.Config           // the base tls config of WithTLSClientConfig
 	RespBodySize    int64
+	Retry           *RetryPolicy
+	Middlewares     []Middleware
+	RaiseForStatus  bool
+	Jar             http.CookieJar
+	Auth            authenticator
+	Signer          request.Signer
+	Proxy           *url.URL
+	NoProxy         []string
+	Pool            *PoolConfig
+	BaseURL         *url.URL
+	DefaultHeaders  map[string]string
+	DefaultQuery    map[string]string
+	UserAgent       string
+
+	tlsOptions       []TLSOption
+	rateLimiter      *rateLimiter
+	circuitBreaker   *circuitBreaker
+	hedger           *hedger
+	downloadProgress *DownloadProgressOption
+	err              error // the first error of options , returned by every request
 }
 
 type ClientOption interface {
@@ -46,22 +70,34 @@ func (d DebugOption) apply(opts *ClientOptions) {
 }
 
 func (t TransportOption) apply(opts *ClientOptions) {
-	opts.Transport = TransportOption{t}
+	opts.Transport = t.RoundTripper
 }
 
 func (c CheckRedirectOption) apply(opts *ClientOptions) {
 	opts.CheckRedirect = CheckRedirectOption(c)
 }
 
-func (t TLSClientConfigOption) apply(opts *ClientOptions) {
-	opts.TLSClientConfig = TLSClientConfigOption(t)
-	opts.Transport = &http.Transport{TLSClientConfig: &opts.TLSClientConfig.Config}
+// apply is a pointer receiver , tls.Config must not be copied
+func (t *TLSClientConfigOption) apply(opts *ClientOptions) {
+	opts.TLSConfig = t.Config.Clone()
+}
+
+func (t TLSConfigOption) apply(opts *ClientOptions) {
+	opts.TLSConfig = t.Config
 }
 
 func (s RespBodySizeOption) apply(opts *ClientOptions) {
 	opts.RespBodySize = int64(s)
 }
 
+func (r RaiseForStatusOption) apply(opts *ClientOptions) {
+	opts.RaiseForStatus = bool(r)
+}
+
+func (s SignerOption) apply(opts *ClientOptions) {
+	opts.Signer = s.Signer
+}
+
 func (d Default) apply(opts *ClientOptions) {
 	// no processing
 }
@@ -71,9 +107,18 @@ func WithTransport(t http.RoundTripper) ClientOption {
 	return TransportOption{t}
 }
 
-// WithInsecureSkipVerify is will override Transport
+// WithInsecureSkipVerify is skip verify the server certificate , it is applied on a clone of Transport
 func WithInsecureSkipVerify() ClientOption {
-	return &TLSClientConfigOption{tls.Config{InsecureSkipVerify: true}}
+	return TLSOption(func(cfg *tls.Config) error {
+		cfg.InsecureSkipVerify = true
+		return nil
+	})
+}
+
+// WithTLSClientConfig is the base tls config instead of the tls config of Transport ,
+// the other tls options are applied on it
+func WithTLSClientConfig(cfg *tls.Config) ClientOption {
+	return TLSConfigOption{cfg}
 }
 
 // WithDebug is whether or not debug
@@ -110,9 +155,25 @@ func WithPreRespBodySize(size ...int64) RespBodySizeOption {
 	return RespBodySizeOption(s)
 }
 
+// WithRaiseForStatus is return *response.StatusError as Response.Err if response is not 2xx
+func WithRaiseForStatus(raise ...bool) ClientOption {
+	r := true
+	if len(raise) > 0 {
+		r = raise[0]
+	}
+	return RaiseForStatusOption(r)
+}
+
+// WithSigner is sign every request just before it is sent , eg: request.HMACSigner , request.SigV4Signer
+func WithSigner(s request.Signer) ClientOption {
+	return SignerOption{s}
+}
+
 type Client struct {
 	Opts       *ClientOptions
 	HttpClient *http.Client
+	stats      *poolStats
+	err        error
 }
 
 func NewClient(opts ...ClientOption) *Client {
@@ -125,61 +186,61 @@ func NewClient(opts ...ClientOption) *Client {
 	for _, o := range opts {
 		o.apply(options)
 	}
+	err := options.err
+	stats := newPoolStats()
+	transport, terr := buildTransport(options, stats)
+	if err == nil {
+		err = terr
+	}
 
 	client := &http.Client{
-		Transport:     options.Transport,
+		Transport:     transport,
 		CheckRedirect: options.CheckRedirect, // 获取301重定向
 		Timeout:       options.Timeout,
+		Jar:           options.Jar,
 	}
 	return &Client{
 		Opts:       options,
 		HttpClient: client,
+		stats:      stats,
+		err:        err,
 	}
 }
 
-// Do is ShortCut http client do method
+// Do is ShortCut http client do method , the request pass through the middleware chain ,
+// the base url , default headers , user agent and default query of client are applied if the request does not set
 func (client *Client) Do(r *request.Request) (*http.Response, error) {
-
-	if client.Opts.Debug {
-		// DEBUG mode request >> connect >> client(option) >> response(option)
-		spew.Dump(r.Opts)                   // print request options
-		clientTrace := defaultclientTrace() // use default trace if open debug
-		req := r.HttpReq.WithContext(httptrace.WithClientTrace(r.HttpReq.Context(), clientTrace))
-		now := time.Now()
-		resp, err := client.HttpClient.Do(req)
-		if err != nil {
-			return resp, err
-		}
-		e := time.Since(now)
-		elapsed := struct{ elapsed time.Duration }{elapsed: e}
-		spew.Dump(elapsed) // print cost time
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return resp, err
-		}
-		resp.Body.Close()                               //  must close
-		resp.Body = io.NopCloser(bytes.NewBuffer(body)) // rewrite resp Body
-		spew.Dump(gjson.ParseBytes(body))               // print response
-		if os.Getenv("REQUEST_CLIENT_DEBUG") != "" {
-			spew.Dump(client.Opts) // print client options
-		}
-		return resp, err
+	if client.err != nil {
+		return nil, client.err
+	}
+	client.applyDefaults(r)
+	resp, err := client.handler()(r)
+	if err == nil {
+		client.trackDownload(resp)
 	}
-	resp, err := client.HttpClient.Do(r.HttpReq)
 	return resp, err
-
 }
 
 func (client *Client) Req(method string, url string, postbody any, args ...map[string]string) response.Response {
-	var body []byte
+	return client.ReqCtx(context.Background(), method, url, postbody, args...)
+}
+
+// ReqCtx is Req with context , the context is used to cancel the request and propagate deadline
+func (client *Client) ReqCtx(ctx context.Context, method string, url string, postbody any, args ...map[string]string) response.Response {
 	query, header := request.Getqueryheader(args...)
-	r, err := request.NewReuqest(
+	return client.Send(
+		ctx,
 		method,
 		url,
 		request.WithBody(postbody),
 		request.WithQuery(query),
 		request.WithHeader(header),
 	)
+}
+
+// Send is build the request by request options , send it and read the whole body
+func (client *Client) Send(ctx context.Context, method string, url string, opts ...request.ReqOption) response.Response {
+	r, err := client.newRequest(ctx, method, url, opts)
 	if err != nil {
 		return response.Response{Resp: nil, Body: nil, Err: err}
 	}
@@ -188,7 +249,12 @@ func (client *Client) Req(method string, url string, postbody any, args ...map[s
 		return response.Response{Resp: resp, Body: nil, Err: err}
 	}
 	defer resp.Body.Close()
+	return client.raiseForStatus(client.readBody(resp))
+}
 
+// readBody is read the whole body of response
+func (client *Client) readBody(resp *http.Response) response.Response {
+	var body []byte
 	// use ReadFull/Copy Instead of ReadAll reduce memory allocation
 	if client.Opts.RespBodySize != 0 {
 		if client.Opts.RespBodySize < resp.ContentLength || resp.ContentLength == -1 {
@@ -227,7 +293,7 @@ func (client *Client) Req(method string, url string, postbody any, args ...map[s
 		}
 
 	}
-	_, err = io.ReadFull(resp.Body, body)
+	_, err := io.ReadFull(resp.Body, body)
 	if err != nil {
 		if err == io.EOF {
 			return response.Response{Resp: resp, Body: body, Err: nil}
@@ -239,14 +305,25 @@ func (client *Client) Req(method string, url string, postbody any, args ...map[s
 
 // ReqRaw just warp the http.Response do not read body , must Close the body after you read the body
 func (client *Client) ReqRaw(method string, url string, postbody any, args ...map[string]string) response.Response {
+	return client.ReqRawCtx(context.Background(), method, url, postbody, args...)
+}
+
+// ReqRawCtx is ReqRaw with context , must Close the body after you read the body
+func (client *Client) ReqRawCtx(ctx context.Context, method string, url string, postbody any, args ...map[string]string) response.Response {
 	query, header := request.Getqueryheader(args...)
-	r, err := request.NewReuqest(
+	return client.SendRaw(
+		ctx,
 		method,
 		url,
 		request.WithBody(postbody),
 		request.WithQuery(query),
 		request.WithHeader(header),
 	)
+}
+
+// SendRaw is Send but do not read body , must Close the body after you read the body
+func (client *Client) SendRaw(ctx context.Context, method string, url string, opts ...request.ReqOption) response.Response {
+	r, err := client.newRequest(ctx, method, url, opts)
 	if err != nil {
 		return response.Response{Resp: nil, Body: nil, Err: err}
 	}
@@ -254,63 +331,77 @@ func (client *Client) ReqRaw(method string, url string, postbody any, args ...ma
 	if err != nil {
 		return response.Response{Resp: resp, Body: nil, Err: err}
 	}
+	if client.Opts.RaiseForStatus {
+		res := response.Response{Resp: resp, Body: nil, Err: nil}
+		if err := res.Raise(); err != nil {
+			// the body will not be returned , read the head of body as error message and close it
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, config.DefaultErrorBodySize))
+			resp.Body.Close()
+			return response.Response{Resp: resp, Body: nil, Err: response.NewStatusError(resp, body)}
+		}
+	}
 	return response.Response{Resp: resp, Body: nil, Err: nil}
 
 }
 
+// raiseForStatus is set the *response.StatusError if WithRaiseForStatus and response is not 2xx
+func (client *Client) raiseForStatus(res response.Response) response.Response {
+	if client.Opts.RaiseForStatus && res.Err == nil {
+		res.Err = res.Raise()
+	}
+	return res
+}
+
 // GET is reuse client
 func (client *Client) GET(url string, args ...map[string]string) response.Response {
 	return client.Req(http.MethodGet, url, nil, args...)
 }
 
+// GETCtx is GET with context
+func (client *Client) GETCtx(ctx context.Context, url string, args ...map[string]string) response.Response {
+	return client.ReqCtx(ctx, http.MethodGet, url, nil, args...)
+}
+
 // POST is shortcut post method with json and client
 func (client *Client) POST(url string, postbody any, args ...map[string]string) response.Response {
 	return client.Req(http.MethodPost, url, postbody, args...)
 
 }
 
+// POSTCtx is POST with context
+func (client *Client) POSTCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
+	return client.ReqCtx(ctx, http.MethodPost, url, postbody, args...)
+}
+
 // PUT is shortcut post method with json and client
 func (client *Client) PUT(url string, postbody any, args ...map[string]string) response.Response {
 	return client.Req(http.MethodPut, url, postbody, args...)
 
 }
 
+// PUTCtx is PUT with context
+func (client *Client) PUTCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
+	return client.ReqCtx(ctx, http.MethodPut, url, postbody, args...)
+}
+
 // PATCH is shortcut post method with json and client
 func (client *Client) PATCH(url string, postbody any, args ...map[string]string) response.Response {
 	return client.Req(http.MethodPatch, url, postbody, args...)
 
 }
 
+// PATCHCtx is PATCH with context
+func (client *Client) PATCHCtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
+	return client.ReqCtx(ctx, http.MethodPatch, url, postbody, args...)
+}
+
 // DELETE is shortcut post method with json and client
 func (client *Client) DELETE(url string, postbody any, args ...map[string]string) response.Response {
 	return client.Req(http.MethodDelete, url, postbody, args...)
 
 }
 
-func defaultclientTrace() (clientTrace *httptrace.ClientTrace) {
-
-	clientTrace = &httptrace.ClientTrace{
-		DNSStart: func(info httptrace.DNSStartInfo) {
-			spew.Dump(info)
-		},
-		DNSDone: func(info httptrace.DNSDoneInfo) {
-			spew.Dump(info)
-		},
-		GetConn: func(hostPort string) {
-			spew.Dump(hostPort)
-		},
-		GotConn: func(gci httptrace.GotConnInfo) {
-			if os.Getenv("REQUEST_CONN_DEBUG") != "" {
-				spew.Dump(gci)
-			} else {
-				reused := struct {
-					Reused bool
-				}{gci.Reused}
-				spew.Dump(reused)
-			}
-
-		},
-	}
-
-	return clientTrace
+// DELETECtx is DELETE with context
+func (client *Client) DELETECtx(ctx context.Context, url string, postbody any, args ...map[string]string) response.Response {
+	return client.ReqCtx(ctx, http.MethodDelete, url, postbody, args...)
 }
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+type CookieJarOption struct{ http.CookieJar }
+
+func (c CookieJarOption) apply(opts *ClientOptions) {
+	opts.Jar = c.CookieJar
+}
+
+// WithCookieJar is keep the cookies between requests like a browser session ,
+// use a new CookieJar if jar is not given
+func WithCookieJar(jar ...http.CookieJar) ClientOption {
+	if len(jar) > 0 {
+		return CookieJarOption{jar[0]}
+	}
+	return CookieJarOption{NewCookieJar()}
+}
+
+// CookieJar is a http.CookieJar which can be saved to and loaded from a json file
+type CookieJar struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	entries map[string]cookieEntry // name;domain;path >> entry
+}
+
+// cookieEntry is the cookie and the url it was set by , json format of the jar file
+type cookieEntry struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// NewCookieJar is a empty CookieJar
+func NewCookieJar() *CookieJar {
+	jar, _ := cookiejar.New(nil) // never return error
+	return &CookieJar{
+		jar:     jar,
+		entries: make(map[string]cookieEntry),
+	}
+}
+
+// LoadCookieJar is load cookies from the json file which is saved by CookieJar.Save ,
+// return a empty CookieJar if the file does not exist
+func LoadCookieJar(path string) (*CookieJar, error) {
+	j := NewCookieJar()
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []cookieEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			return nil, err
+		}
+		j.SetCookies(u, []*http.Cookie{e.Cookie})
+	}
+	return j, nil
+}
+
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	setBy := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}
+	for _, c := range cookies {
+		domain := c.Domain
+		if domain == "" {
+			domain = u.Hostname()
+		}
+		key := c.Name + ";" + domain + ";" + c.Path
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(now)) {
+			delete(j.entries, key)
+			continue
+		}
+		saved := *c
+		if saved.MaxAge > 0 {
+			// save the absolute expires , so the cookie does not live longer after reload
+			saved.Expires = now.Add(time.Duration(saved.MaxAge) * time.Second)
+			saved.MaxAge = 0
+		}
+		j.entries[key] = cookieEntry{URL: setBy.String(), Cookie: &saved}
+	}
+}
+
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+// Save is write the unexpired cookies to a json file
+func (j *CookieJar) Save(path string) error {
+	j.mu.Lock()
+	now := time.Now()
+	entries := make([]cookieEntry, 0, len(j.entries))
+	for key, e := range j.entries {
+		if !e.Cookie.Expires.IsZero() && e.Cookie.Expires.Before(now) {
+			delete(j.entries, key)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	j.mu.Unlock()
+
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
+
+// Cookies is the cookies will be sent to the url , nil if client has no cookie jar
+func (client *Client) Cookies(rawurl string) []*http.Cookie {
+	if client.HttpClient.Jar == nil {
+		return nil
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil
+	}
+	return client.HttpClient.Jar.Cookies(u)
+}
+
+// SetCookies is add cookies of the url to client cookie jar , eg: a session id from other place
+func (client *Client) SetCookies(rawurl string, cookies ...*http.Cookie) error {
+	if client.HttpClient.Jar == nil {
+		return errors.New("client has no cookie jar , use WithCookieJar")
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+	client.HttpClient.Jar.SetCookies(u, cookies)
+	return nil
+}
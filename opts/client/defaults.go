@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/zengzhengrong/request/request"
+)
+
+type BaseURLOption string
+type DefaultHeadersOption map[string]string
+type DefaultQueryOption map[string]string
+type UserAgentOption string
+
+func (b BaseURLOption) apply(opts *ClientOptions) {
+	u, err := url.Parse(string(b))
+	if err != nil {
+		opts.setErr(fmt.Errorf("invalid base url: %w", err))
+		return
+	}
+	if !u.IsAbs() || u.Host == "" {
+		opts.setErr(fmt.Errorf("invalid base url %s: must be absolute", u.Redacted()))
+		return
+	}
+	// https://api.x/v2 is the same as https://api.x/v2/ , so the relative path is resolved under v2
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+		if u.RawPath != "" {
+			u.RawPath += "/"
+		}
+	}
+	opts.BaseURL = u
+}
+
+func (d DefaultHeadersOption) apply(opts *ClientOptions) {
+	if opts.DefaultHeaders == nil {
+		opts.DefaultHeaders = make(map[string]string, len(d))
+	}
+	for k, v := range d {
+		opts.DefaultHeaders[k] = v
+	}
+}
+
+func (d DefaultQueryOption) apply(opts *ClientOptions) {
+	if opts.DefaultQuery == nil {
+		opts.DefaultQuery = make(map[string]string, len(d))
+	}
+	for k, v := range d {
+		opts.DefaultQuery[k] = v
+	}
+}
+
+func (u UserAgentOption) apply(opts *ClientOptions) {
+	opts.UserAgent = string(u)
+}
+
+// WithBaseURL is resolve the relative url of every request by url.ResolveReference ,
+// eg: base https://api.x/v2 and users/1 is https://api.x/v2/users/1 ,
+// but /users/1 is https://api.x/users/1 because it is relative to the root , the absolute url is not changed
+func WithBaseURL(baseURL string) ClientOption {
+	return BaseURLOption(baseURL)
+}
+
+// WithDefaultHeaders is set the headers to every request , the header of request is prior to them ,
+// can be call many times
+func WithDefaultHeaders(header map[string]string) ClientOption {
+	return DefaultHeadersOption(header)
+}
+
+// WithDefaultQuery is add the query to every request , the query of request is prior to them ,
+// can be call many times
+func WithDefaultQuery(query map[string]string) ClientOption {
+	return DefaultQueryOption(query)
+}
+
+// WithUserAgent is set User-Agent header of every request , the header of request is prior to it
+func WithUserAgent(userAgent string) ClientOption {
+	return UserAgentOption(userAgent)
+}
+
+// resolveURL is resolve the url by base url
+func (client *Client) resolveURL(rawurl string) (string, error) {
+	if client.Opts.BaseURL == nil {
+		return rawurl, nil
+	}
+	ref, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+	return client.Opts.BaseURL.ResolveReference(ref).String(), nil
+}
+
+// newRequest is build the request of Send and SendRaw with the base url of client ,
+// the other defaults are applied by Do
+func (client *Client) newRequest(ctx context.Context, method string, rawurl string, opts []request.ReqOption) (*request.Request, error) {
+	rawurl, err := client.resolveURL(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts[:len(opts):len(opts)], request.WithContext(ctx))
+	return request.NewReuqest(method, rawurl, opts...)
+}
+
+// applyDefaults is apply the base url , default headers , user agent and default query of client ,
+// the values of request are kept , it is called by Do so the request built by request.NewReuqest get them too
+func (client *Client) applyDefaults(r *request.Request) {
+	u := r.HttpReq.URL
+	if client.Opts.BaseURL != nil && !u.IsAbs() {
+		u = client.Opts.BaseURL.ResolveReference(u)
+		r.HttpReq.URL = u
+		r.HttpReq.Host = u.Host
+		r.Opts.Url = u.String()
+	}
+
+	header := r.HttpReq.Header
+	for k, v := range client.Opts.DefaultHeaders {
+		if header.Get(k) == "" {
+			header.Set(k, v)
+		}
+	}
+	if client.Opts.UserAgent != "" && header.Get("User-Agent") == "" {
+		header.Set("User-Agent", client.Opts.UserAgent)
+	}
+
+	if len(client.Opts.DefaultQuery) > 0 {
+		query := u.Query()
+		missing := url.Values{}
+		for k, v := range client.Opts.DefaultQuery {
+			if _, ok := query[k]; !ok {
+				missing.Set(k, v)
+			}
+		}
+		if len(missing) > 0 {
+			encoded := missing.Encode()
+			if strings.Trim(u.RawQuery, "&") == "" {
+				u.RawQuery = encoded
+			} else {
+				u.RawQuery = strings.TrimRight(u.RawQuery, "&") + "&" + encoded
+			}
+			r.Opts.Url = u.String()
+			if r.Opts.Query == "" {
+				r.Opts.Query = encoded
+			} else {
+				r.Opts.Query = r.Opts.Query + "&" + encoded
+			}
+		}
+	}
+}
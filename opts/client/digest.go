@@ -0,0 +1,205 @@
+package client
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/zengzhengrong/request/request"
+)
+
+// WithDigestAuth is rfc7616 Digest Authorization , the first request of a server get the 401 challenge ,
+// then the request is replayed with the digest response , the later requests reuse the challenge with nonce count
+func WithDigestAuth(username string, password string) ClientOption {
+	return AuthOption{&digestAuth{
+		username:   username,
+		password:   password,
+		challenges: make(map[string]*digestChallenge),
+	}}
+}
+
+// digestAlgorithms is supported algorithms , the higher priority is preferred
+var digestAlgorithms = map[string]struct {
+	hash     func() hash.Hash
+	priority int
+}{
+	"SHA-256":      {sha256.New, 4},
+	"SHA-256-SESS": {sha256.New, 3},
+	"MD5":          {md5.New, 2},
+	"MD5-SESS":     {md5.New, 1},
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string // only auth is supported , empty if server does not send qop
+	nc        uint32 // nonce count of this nonce
+}
+
+type digestAuth struct {
+	username string
+	password string
+
+	mu         sync.Mutex
+	challenges map[string]*digestChallenge // host >> last challenge
+}
+
+func (d *digestAuth) authorize(client *Client, r *request.Request) error {
+	d.mu.Lock()
+	c, ok := d.challenges[r.HttpReq.URL.Host]
+	if !ok {
+		d.mu.Unlock()
+		// send without Authorization to get the challenge
+		return nil
+	}
+	c.nc++
+	nc := fmt.Sprintf("%08x", c.nc)
+	challenge := *c
+	d.mu.Unlock()
+
+	h := digestAlgorithms[strings.ToUpper(challenge.algorithm)].hash
+	hexHash := func(s string) string {
+		hh := h()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+	cnonce := newCnonce()
+	uri := r.HttpReq.URL.RequestURI()
+	ha1 := hexHash(d.username + ":" + challenge.realm + ":" + d.password)
+	if strings.HasSuffix(strings.ToUpper(challenge.algorithm), "-SESS") {
+		ha1 = hexHash(ha1 + ":" + challenge.nonce + ":" + cnonce)
+	}
+	ha2 := hexHash(r.HttpReq.Method + ":" + uri)
+
+	var response string
+	if challenge.qop == "" {
+		response = hexHash(ha1 + ":" + challenge.nonce + ":" + ha2)
+	} else {
+		response = hexHash(strings.Join([]string{ha1, challenge.nonce, nc, cnonce, challenge.qop, ha2}, ":"))
+	}
+
+	params := []string{
+		fmt.Sprintf(`username="%s"`, d.username),
+		fmt.Sprintf(`realm="%s"`, challenge.realm),
+		fmt.Sprintf(`nonce="%s"`, challenge.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`algorithm=%s`, challenge.algorithm),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if challenge.qop != "" {
+		params = append(params, "qop="+challenge.qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if challenge.opaque != "" {
+		params = append(params, fmt.Sprintf(`opaque="%s"`, challenge.opaque))
+	}
+	r.HttpReq.Header.Set("Authorization", "Digest "+strings.Join(params, ", "))
+	return nil
+}
+
+// invalidate is save the new challenge of server , return false if there is no supported challenge
+func (d *digestAuth) invalidate(r *request.Request, resp *http.Response) bool {
+	var best *digestChallenge
+	for _, header := range resp.Header.Values("WWW-Authenticate") {
+		c, ok := parseDigestChallenge(header)
+		if !ok {
+			continue
+		}
+		if best == nil || digestAlgorithms[strings.ToUpper(c.algorithm)].priority > digestAlgorithms[strings.ToUpper(best.algorithm)].priority {
+			best = c
+		}
+	}
+	if best == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.challenges[r.HttpReq.URL.Host] = best
+	return true
+}
+
+// parseDigestChallenge is parse the WWW-Authenticate header ,
+// eg: Digest realm="x", qop="auth,auth-int", algorithm=SHA-256, nonce="y", opaque="z"
+func parseDigestChallenge(header string) (*digestChallenge, bool) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return nil, false
+	}
+	params := parseAuthParams(rest)
+	c := &digestChallenge{
+		realm:     params["realm"],
+		nonce:     params["nonce"],
+		opaque:    params["opaque"],
+		algorithm: params["algorithm"],
+	}
+	if c.algorithm == "" {
+		c.algorithm = "MD5"
+	}
+	if _, ok := digestAlgorithms[strings.ToUpper(c.algorithm)]; !ok || c.nonce == "" {
+		return nil, false
+	}
+	if qop, ok := params["qop"]; ok {
+		for _, q := range strings.Split(qop, ",") {
+			if strings.TrimSpace(q) == "auth" {
+				c.qop = "auth"
+			}
+		}
+		// auth-int only is not supported
+		if c.qop == "" {
+			return nil, false
+		}
+	}
+	return c, true
+}
+
+// parseAuthParams is parse the comma separated key=value or key="quoted value" list
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return params
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		var value strings.Builder
+		if strings.HasPrefix(s, `"`) {
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				value.WriteByte(s[i])
+			}
+			if i < len(s) {
+				i++ // skip the closing quote
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value.WriteString(strings.TrimSpace(s[:end]))
+			s = s[end:]
+		}
+		params[key] = value.String()
+	}
+}
+
+func newCnonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,516 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/request"
+	"github.com/zengzhengrong/request/response"
+	"golang.org/x/sync/errgroup"
+)
+
+// DownloadOption is the option of Client.Download
+type DownloadOption func(o *downloadOptions)
+
+type downloadOptions struct {
+	segments int
+	newHash  func() hash.Hash
+	checksum string
+	progress request.ProgressFunc
+	interval time.Duration
+	reqOpts  []request.ReqOption
+}
+
+// WithSegments is download n ranges of the file in parallel , it fallback to one stream if the server does not support range
+func WithSegments(n int) DownloadOption {
+	return func(o *downloadOptions) {
+		o.segments = n
+	}
+}
+
+// WithChecksum is verify the hex checksum of the downloaded file , eg: WithChecksum(sha256.New, "e3b0c442...") ,
+// the file is removed if the checksum does not match
+func WithChecksum(newHash func() hash.Hash, expected string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.newHash = newHash
+		o.checksum = expected
+	}
+}
+
+// WithProgress is report the download progress at most once per interval , the default is request.DefaultProgressInterval
+func WithProgress(fn request.ProgressFunc, interval ...time.Duration) DownloadOption {
+	return func(o *downloadOptions) {
+		o.progress = fn
+		if len(interval) > 0 {
+			o.interval = interval[0]
+		}
+	}
+}
+
+// WithRequestOptions is the options of download requests , eg: header , query
+func WithRequestOptions(opts ...request.ReqOption) DownloadOption {
+	return func(o *downloadOptions) {
+		o.reqOpts = append(o.reqOpts, opts...)
+	}
+}
+
+// downloadMetaInterval is the interval of saving the progress of segments
+var downloadMetaInterval = time.Second
+
+// downloadMeta is saved beside the part file , so the download can be resumed
+type downloadMeta struct {
+	URL          string            `json:"url"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified string            `json:"last_modified,omitempty"`
+	Size         int64             `json:"size"` // -1 is unknown
+	Segments     []downloadSegment `json:"segments,omitempty"`
+}
+
+// downloadSegment is the range [Start, End] and the bytes already downloaded
+type downloadSegment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Done  int64 `json:"done"`
+}
+
+func newDownloadMeta(url string, resp *http.Response, size int64) *downloadMeta {
+	return &downloadMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Size:         size,
+	}
+}
+
+// validator is the If-Range value , weak etag can not be used
+func (m *downloadMeta) validator() string {
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+func loadDownloadMeta(path string, url string) *downloadMeta {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var meta downloadMeta
+	if err := json.Unmarshal(b, &meta); err != nil || meta.URL != url {
+		return nil
+	}
+	return &meta
+}
+
+// save is write the meta by a temp file and rename , so a crash during saving does not break the old one
+func (m *downloadMeta) save(path string) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// download is the state of a Client.Download
+type download struct {
+	client   *Client
+	url      string
+	partPath string
+	metaPath string
+	opts     *downloadOptions
+	tracker  *request.ProgressTracker
+}
+
+// Download is stream the url to dest by a temp file dest.part , the interrupted download is resumed by
+// Range and If-Range (ETag or Last-Modified) next time , the file is renamed to dest after it is completed and verified ,
+// the Timeout of client is not used because a large file take long , use ctx to limit the download
+func (client *Client) Download(ctx context.Context, url string, dest string, opts ...DownloadOption) error {
+	o := &downloadOptions{segments: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.reqOpts = append(o.reqOpts, request.WithStream())
+	d := &download{
+		client:   client.withoutTimeout(),
+		url:      url,
+		partPath: dest + ".part",
+		metaPath: dest + ".part.json",
+		opts:     o,
+		tracker:  request.NewProgressTracker(o.progress, o.interval),
+	}
+	meta := loadDownloadMeta(d.metaPath, url)
+	if meta == nil {
+		if err := d.reset(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if o.segments > 1 {
+		err = d.segmented(ctx, meta)
+	} else {
+		err = d.single(ctx, meta)
+	}
+	if err != nil {
+		return err
+	}
+	d.tracker.Finish()
+	if err := d.verify(); err != nil {
+		d.reset()
+		return err
+	}
+	if err := os.Rename(d.partPath, dest); err != nil {
+		return err
+	}
+	os.Remove(d.metaPath)
+	return nil
+}
+
+// withoutTimeout is a copy of client whose http client has no Timeout , the ctx of request limit it
+func (client *Client) withoutTimeout() *Client {
+	if client.HttpClient == nil || client.HttpClient.Timeout == 0 {
+		return client
+	}
+	c := *client
+	hc := *client.HttpClient
+	hc.Timeout = 0
+	c.HttpClient = &hc
+	return &c
+}
+
+// reset is remove the part file and meta , the download start from the beginning
+func (d *download) reset() error {
+	for _, path := range []string{d.partPath, d.metaPath} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *download) get(ctx context.Context, header map[string]string) (*http.Response, error) {
+	r, err := d.client.newRequest(ctx, http.MethodGet, d.url, d.opts.reqOpts)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		r.HttpReq.Header.Set(k, v)
+	}
+	return d.client.Do(r)
+}
+
+// statusError is close the response and return *response.StatusError
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, config.DefaultErrorBodySize))
+	resp.Body.Close()
+	return response.NewStatusError(resp, body)
+}
+
+// single is download by one stream , append to the part file if it can be resumed
+func (d *download) single(ctx context.Context, meta *downloadMeta) error {
+	var offset int64
+	if meta != nil && meta.Segments == nil && meta.validator() != "" {
+		if info, err := os.Stat(d.partPath); err == nil {
+			offset = info.Size()
+		}
+	}
+	header := map[string]string{}
+	if offset > 0 {
+		header["Range"] = fmt.Sprintf("bytes=%d-", offset)
+		header["If-Range"] = meta.validator()
+	}
+	resp, err := d.get(ctx, header)
+	if err != nil {
+		return err
+	}
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		start, _, _, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			resp.Body.Close()
+			return fmt.Errorf("download: invalid Content-Range %q for offset %d", resp.Header.Get("Content-Range"), offset)
+		}
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		discard(resp)
+		if meta.Size == offset {
+			// completed but not renamed
+			d.tracker.SetTotal(offset)
+			d.tracker.Resume(offset)
+			return nil
+		}
+		if err := d.reset(); err != nil {
+			return err
+		}
+		return d.single(ctx, nil)
+	case resp.StatusCode == http.StatusOK:
+		// the first download or the file is changed
+		offset = 0
+		meta = newDownloadMeta(d.url, resp, resp.ContentLength)
+	default:
+		return statusError(resp)
+	}
+	defer resp.Body.Close()
+	if err := meta.save(d.metaPath); err != nil {
+		return err
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(d.partPath, flag, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	d.tracker.SetTotal(meta.Size)
+	d.tracker.Resume(offset)
+	n, err := io.Copy(f, d.tracker.Reader(resp.Body))
+	if err != nil {
+		return err
+	}
+	if meta.Size >= 0 && offset+n != meta.Size {
+		return fmt.Errorf("download: %w , got %d of %d bytes", io.ErrUnexpectedEOF, offset+n, meta.Size)
+	}
+	return f.Close()
+}
+
+// segmented is download the ranges in parallel by WriteAt , fallback to single if the size is unknown or range is not supported
+func (d *download) segmented(ctx context.Context, meta *downloadMeta) error {
+	if meta != nil && meta.Segments != nil {
+		// the part file is removed or not preallocated , the segments can not be resumed
+		if info, err := os.Stat(d.partPath); err != nil || info.Size() != meta.Size {
+			meta = nil
+		}
+	}
+	// the segments without validator can not be resumed , the file may be changed between the requests
+	if meta == nil || meta.Segments == nil || meta.validator() == "" {
+		resp, err := d.get(ctx, map[string]string{"Range": "bytes=0-0"})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusPartialContent {
+			if resp.StatusCode != http.StatusOK {
+				return statusError(resp)
+			}
+			discard(resp)
+			return d.single(ctx, nil)
+		}
+		discard(resp)
+		_, _, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || size <= 0 {
+			return d.single(ctx, nil)
+		}
+		meta = newDownloadMeta(d.url, resp, size)
+		if meta.validator() == "" {
+			// without ETag or Last-Modified , the segments could be the bytes of different versions
+			return d.single(ctx, nil)
+		}
+		meta.Segments = splitSegments(size, d.opts.segments)
+		if err := d.reset(); err != nil {
+			return err
+		}
+		f, err := os.Create(d.partPath)
+		if err != nil {
+			return err
+		}
+		err = f.Truncate(size)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if err := meta.save(d.metaPath); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(d.partPath, os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	d.tracker.SetTotal(meta.Size)
+	for _, seg := range meta.Segments {
+		d.tracker.Resume(seg.Done)
+	}
+
+	// mu guard the Done of segments , the meta is saved periodically so the download can be resumed after a crash
+	var (
+		changed int32
+		mu      sync.Mutex
+	)
+	stopSave := make(chan struct{})
+	saved := make(chan struct{})
+	go func() {
+		defer close(saved)
+		ticker := time.NewTicker(downloadMetaInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopSave:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				meta.save(d.metaPath)
+				mu.Unlock()
+			}
+		}
+	}()
+	g, gctx := errgroup.WithContext(ctx)
+	for i := range meta.Segments {
+		seg := &meta.Segments[i]
+		if seg.Start+seg.Done > seg.End {
+			continue
+		}
+		g.Go(func() error {
+			mu.Lock()
+			done := seg.Done
+			mu.Unlock()
+			header := map[string]string{"Range": fmt.Sprintf("bytes=%d-%d", seg.Start+done, seg.End)}
+			if v := meta.validator(); v != "" {
+				header["If-Range"] = v
+			}
+			resp, err := d.get(gctx, header)
+			if err != nil {
+				return err
+			}
+			if resp.StatusCode == http.StatusOK {
+				discard(resp)
+				atomic.StoreInt32(&changed, 1)
+				return errors.New("download: the file is changed on server , download it again")
+			}
+			if resp.StatusCode != http.StatusPartialContent {
+				return statusError(resp)
+			}
+			defer resp.Body.Close()
+			if start, _, _, ok := parseContentRange(resp.Header.Get("Content-Range")); !ok || start != seg.Start+done {
+				return fmt.Errorf("download: invalid Content-Range %q", resp.Header.Get("Content-Range"))
+			}
+			buf := make([]byte, 32*1024)
+			for seg.Start+done <= seg.End {
+				n, rerr := resp.Body.Read(buf)
+				if remain := seg.End - seg.Start - done + 1; int64(n) > remain {
+					n = int(remain)
+				}
+				if n > 0 {
+					if _, err := f.WriteAt(buf[:n], seg.Start+done); err != nil {
+						return err
+					}
+					// the bytes are written before they are recorded in meta
+					done += int64(n)
+					mu.Lock()
+					seg.Done = done
+					mu.Unlock()
+					d.tracker.Add(int64(n))
+				}
+				if rerr == io.EOF {
+					break
+				}
+				if rerr != nil {
+					return rerr
+				}
+			}
+			if seg.Start+done <= seg.End {
+				return fmt.Errorf("download: segment %d-%d: %w", seg.Start, seg.End, io.ErrUnexpectedEOF)
+			}
+			return nil
+		})
+	}
+	err = g.Wait()
+	close(stopSave)
+	<-saved
+	if atomic.LoadInt32(&changed) == 1 {
+		d.reset()
+		return err
+	}
+	// keep the progress of segments for resume
+	if serr := meta.save(d.metaPath); err == nil {
+		err = serr
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func splitSegments(size int64, n int) []downloadSegment {
+	if int64(n) > size {
+		n = int(size)
+	}
+	chunk := size / int64(n)
+	segments := make([]downloadSegment, n)
+	for i := range segments {
+		segments[i].Start = int64(i) * chunk
+		segments[i].End = segments[i].Start + chunk - 1
+	}
+	segments[n-1].End = size - 1
+	return segments
+}
+
+// parseContentRange is parse "bytes start-end/total" , total is -1 if it is *
+func parseContentRange(v string) (int64, int64, int64, bool) {
+	if !strings.HasPrefix(v, "bytes ") {
+		return 0, 0, 0, false
+	}
+	v = strings.TrimPrefix(v, "bytes ")
+	rng, totalStr, ok := strings.Cut(v, "/")
+	if !ok {
+		return 0, 0, 0, false
+	}
+	startStr, endStr, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, 0, false
+	}
+	start, err1 := strconv.ParseInt(startStr, 10, 64)
+	end, err2 := strconv.ParseInt(endStr, 10, 64)
+	if err1 != nil || err2 != nil || start > end {
+		return 0, 0, 0, false
+	}
+	total := int64(-1)
+	if totalStr != "*" {
+		t, err := strconv.ParseInt(totalStr, 10, 64)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		total = t
+	}
+	return start, end, total, true
+}
+
+// verify is compare the checksum of part file
+func (d *download) verify() error {
+	if d.opts.newHash == nil {
+		return nil
+	}
+	f, err := os.Open(d.partPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := d.opts.newHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(sum, d.opts.checksum) {
+		return fmt.Errorf("%w: expect %s , got %s", config.ChecksumError, d.opts.checksum, sum)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/zengzhengrong/request/request"
+	"github.com/zengzhengrong/request/response"
+)
+
+// Fetch is send the request and decode the response body into T by the codec of Content-Type ,
+// the error is *response.StatusError if response is not 2xx , *response.DecodeError if decode failed
+func Fetch[T any](ctx context.Context, c *Client, method string, url string, opts ...request.ReqOption) (T, *response.Response, error) {
+	var v T
+	resp := c.Send(ctx, method, url, opts...)
+	if err := resp.Raise(); err != nil {
+		return v, &resp, err
+	}
+	if len(resp.Body) == 0 {
+		return v, &resp, nil
+	}
+	if err := resp.Decode(&v); err != nil {
+		return v, &resp, &response.DecodeError{ContentType: resp.Resp.Header.Get("Content-Type"), Err: err}
+	}
+	return v, &resp, nil
+}
+
+// Get is GET the url and decode the response body into T
+func Get[T any](ctx context.Context, c *Client, url string, opts ...request.ReqOption) (T, *response.Response, error) {
+	return Fetch[T](ctx, c, http.MethodGet, url, opts...)
+}
+
+// Post is POST the body and decode the response body into T
+func Post[T any](ctx context.Context, c *Client, url string, postbody any, opts ...request.ReqOption) (T, *response.Response, error) {
+	return Fetch[T](ctx, c, http.MethodPost, url, withBody(postbody, opts)...)
+}
+
+// Put is PUT the body and decode the response body into T
+func Put[T any](ctx context.Context, c *Client, url string, postbody any, opts ...request.ReqOption) (T, *response.Response, error) {
+	return Fetch[T](ctx, c, http.MethodPut, url, withBody(postbody, opts)...)
+}
+
+// Patch is PATCH the body and decode the response body into T
+func Patch[T any](ctx context.Context, c *Client, url string, postbody any, opts ...request.ReqOption) (T, *response.Response, error) {
+	return Fetch[T](ctx, c, http.MethodPatch, url, withBody(postbody, opts)...)
+}
+
+// Delete is DELETE the url and decode the response body into T
+func Delete[T any](ctx context.Context, c *Client, url string, opts ...request.ReqOption) (T, *response.Response, error) {
+	return Fetch[T](ctx, c, http.MethodDelete, url, opts...)
+}
+
+// withBody is put the body option in front , so the caller options can override it
+func withBody(postbody any, opts []request.ReqOption) []request.ReqOption {
+	return append([]request.ReqOption{request.WithBody(postbody)}, opts...)
+}
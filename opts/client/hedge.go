@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/zengzhengrong/request/request"
+)
+
+type HedgingOption struct {
+	delay     time.Duration
+	maxHedges int
+}
+
+func (h HedgingOption) apply(opts *ClientOptions) {
+	if h.maxHedges <= 0 {
+		opts.hedger = nil
+		return
+	}
+	opts.hedger = &hedger{delay: h.delay, maxHedges: h.maxHedges}
+}
+
+// WithHedging is send a copy of request if there is no response after delay , at most maxHedges copies ,
+// the first successful response win and the others are canceled ,
+// only GET HEAD OPTIONS requests without body are hedged , see Client.HedgingStats
+func WithHedging(delay time.Duration, maxHedges int) ClientOption {
+	return HedgingOption{delay: delay, maxHedges: maxHedges}
+}
+
+// HedgingStats is the counts of hedged requests
+type HedgingStats struct {
+	Requests  int64 // requests which can be hedged
+	Hedges    int64 // copies sent after delay
+	HedgeWins int64 // requests which the response of a copy win
+}
+
+// HedgingStats is the counts of hedged requests , zero if the client has no WithHedging
+func (client *Client) HedgingStats() HedgingStats {
+	h := client.Opts.hedger
+	if h == nil {
+		return HedgingStats{}
+	}
+	return HedgingStats{
+		Requests:  atomic.LoadInt64(&h.requests),
+		Hedges:    atomic.LoadInt64(&h.hedges),
+		HedgeWins: atomic.LoadInt64(&h.wins),
+	}
+}
+
+type hedger struct {
+	delay     time.Duration
+	maxHedges int
+
+	requests int64
+	hedges   int64
+	wins     int64
+}
+
+type hedgeResult struct {
+	index  int
+	resp   *http.Response
+	err    error
+	cancel context.CancelFunc
+}
+
+// succeeded is the response which need not to wait for the others
+func (res hedgeResult) succeeded() bool {
+	return res.err == nil && res.resp.StatusCode < http.StatusInternalServerError
+}
+
+// release is close the response and cancel its request
+func (res hedgeResult) release() {
+	discard(res.resp)
+	res.cancel()
+}
+
+// finish is return the result to caller , the request context is canceled after the body is closed
+func (res hedgeResult) finish() (*http.Response, error) {
+	if res.err != nil || res.resp.Body == nil {
+		res.cancel()
+		return res.resp, res.err
+	}
+	res.resp.Body = &cancelBody{ReadCloser: res.resp.Body, cancel: res.cancel}
+	return res.resp, res.err
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func hedgeable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+	// the body can not be read by copies at the same time
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+func (h *hedger) middleware(next Handler) Handler {
+	return func(r *request.Request) (*http.Response, error) {
+		if !hedgeable(r.HttpReq) {
+			return next(r)
+		}
+		atomic.AddInt64(&h.requests, 1)
+		parent := r.HttpReq.Context()
+		results := make(chan hedgeResult, h.maxHedges+1)
+		var cancels []context.CancelFunc
+		launch := func() {
+			ctx, cancel := context.WithCancel(parent)
+			cancels = append(cancels, cancel)
+			copied := &request.Request{Opts: r.Opts, HttpReq: r.HttpReq.Clone(ctx)}
+			index := len(cancels) - 1
+			go func() {
+				resp, err := next(copied)
+				results <- hedgeResult{index: index, resp: resp, err: err, cancel: cancel}
+			}()
+		}
+
+		launch()
+		inflight := 1
+		timer := time.NewTimer(h.delay)
+		defer timer.Stop()
+		var failed *hedgeResult
+		for {
+			select {
+			case <-timer.C:
+				if len(cancels) <= h.maxHedges {
+					atomic.AddInt64(&h.hedges, 1)
+					launch()
+					inflight++
+					timer.Reset(h.delay)
+				}
+			case res := <-results:
+				inflight--
+				if res.succeeded() {
+					if res.index > 0 {
+						atomic.AddInt64(&h.wins, 1)
+					}
+					for i, cancel := range cancels {
+						if i != res.index {
+							cancel()
+						}
+					}
+					if failed != nil {
+						failed.release()
+					}
+					// close the responses of the losers
+					go func(n int) {
+						for ; n > 0; n-- {
+							(<-results).release()
+						}
+					}(inflight)
+					return res.finish()
+				}
+				// keep the last failure , return it if all copies failed
+				if failed != nil {
+					failed.release()
+				}
+				failed = &res
+				if inflight == 0 {
+					return failed.finish()
+				}
+			}
+		}
+	}
+}
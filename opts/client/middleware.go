@@ -0,0 +1,165 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/tidwall/gjson"
+	"github.com/zengzhengrong/request/request"
+)
+
+// Handler is send the request and return the response
+type Handler func(r *request.Request) (*http.Response, error)
+
+// Middleware is wrap the next handler , eg: add header , log , metrics
+type Middleware func(next Handler) Handler
+
+type MiddlewareOption []Middleware
+
+func (m MiddlewareOption) apply(opts *ClientOptions) {
+	opts.Middlewares = append(opts.Middlewares, m...)
+}
+
+// WithMiddleware is add middlewares to client , can be call many times ,
+// the first added middleware is the outermost one ,
+// the order of request is: middlewares >> hedging >> retry >> circuit breaker >> rate limit >> auth >> sign >> debug >> transport
+func WithMiddleware(m ...Middleware) ClientOption {
+	return MiddlewareOption(m)
+}
+
+// handler is build the middleware chain of every request
+func (client *Client) handler() Handler {
+	h := Handler(client.send)
+	if client.Opts.Debug {
+		h = debugMiddleware(client.Opts)(h)
+	}
+	h = signMiddleware(client.Opts.Signer)(h)
+	if client.Opts.Auth != nil {
+		h = authMiddleware(client, client.Opts.Auth)(h)
+	}
+	if client.Opts.rateLimiter != nil {
+		h = client.Opts.rateLimiter.middleware(h)
+	}
+	if client.Opts.circuitBreaker != nil {
+		h = client.Opts.circuitBreaker.middleware(h)
+	}
+	if client.Opts.Retry != nil {
+		h = client.Opts.Retry.middleware(h)
+	}
+	if client.Opts.hedger != nil {
+		h = client.Opts.hedger.middleware(h)
+	}
+	for i := len(client.Opts.Middlewares) - 1; i >= 0; i-- {
+		h = client.Opts.Middlewares[i](h)
+	}
+	return h
+}
+
+// send is the last handler of chain , just send the request by http client
+func (client *Client) send(r *request.Request) (*http.Response, error) {
+	host := client.stats.startRequest(r.HttpReq)
+	resp, err := client.HttpClient.Do(r.HttpReq)
+	return client.stats.trackRequest(host, resp, err), err
+}
+
+// signMiddleware is sign a copy of request just before send , the signer of request is prior to client
+func signMiddleware(signer request.Signer) Middleware {
+	return func(next Handler) Handler {
+		return func(r *request.Request) (*http.Response, error) {
+			s := signer
+			if r.Opts.Signer != nil {
+				s = r.Opts.Signer
+			}
+			if s == nil {
+				return next(r)
+			}
+			signed := &request.Request{Opts: r.Opts, HttpReq: r.HttpReq.Clone(r.HttpReq.Context())}
+			if err := s.Sign(signed); err != nil {
+				return nil, err
+			}
+			return next(signed)
+		}
+	}
+}
+
+// debugMiddleware is the DEBUG mode request >> connect >> client(option) >> response(option)
+func debugMiddleware(opts *ClientOptions) Middleware {
+	return func(next Handler) Handler {
+		return func(r *request.Request) (*http.Response, error) {
+			spew.Dump(r.Opts)                   // print request options
+			clientTrace := defaultclientTrace() // use default trace if open debug
+			traced := &request.Request{
+				Opts:    r.Opts,
+				HttpReq: r.HttpReq.WithContext(httptrace.WithClientTrace(r.HttpReq.Context(), clientTrace)),
+			}
+			now := time.Now()
+			resp, err := next(traced)
+			if err != nil {
+				return resp, err
+			}
+			e := time.Since(now)
+			elapsed := struct{ elapsed time.Duration }{elapsed: e}
+			spew.Dump(elapsed) // print cost time
+			if r.Opts.Stream || isStream(resp) {
+				// the stream could be endless or too large , print the header instead of reading it
+				spew.Dump(resp.Header)
+			} else {
+				body, err := io.ReadAll(resp.Body)
+				if err != nil {
+					return resp, err
+				}
+				resp.Body.Close()                               //  must close
+				resp.Body = io.NopCloser(bytes.NewBuffer(body)) // rewrite resp Body
+				spew.Dump(gjson.ParseBytes(body))               // print response
+			}
+			if os.Getenv("REQUEST_CLIENT_DEBUG") != "" {
+				spew.Dump(opts) // print client options
+			}
+			return resp, err
+		}
+	}
+}
+
+// isStream is the response body is a stream by Content-Type
+func isStream(resp *http.Response) bool {
+	ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch ct {
+	case "text/event-stream", "application/x-ndjson", "application/ndjson", "application/octet-stream":
+		return true
+	}
+	return false
+}
+
+func defaultclientTrace() (clientTrace *httptrace.ClientTrace) {
+
+	clientTrace = &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			spew.Dump(info)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			spew.Dump(info)
+		},
+		GetConn: func(hostPort string) {
+			spew.Dump(hostPort)
+		},
+		GotConn: func(gci httptrace.GotConnInfo) {
+			if os.Getenv("REQUEST_CONN_DEBUG") != "" {
+				spew.Dump(gci)
+			} else {
+				reused := struct {
+					Reused bool
+				}{gci.Reused}
+				spew.Dump(reused)
+			}
+
+		},
+	}
+
+	return clientTrace
+}
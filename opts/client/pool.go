@@ -0,0 +1,292 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// PoolConfig is the connection pool and timeouts of transport , zero value keep the setting of Transport
+type PoolConfig struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration // negative is disable tcp keep-alive
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	ForceAttemptHTTP2     *bool
+}
+
+// PoolOption is tune the connection pool of a cloned Transport
+type PoolOption func(pool *PoolConfig)
+
+func (p PoolOption) apply(opts *ClientOptions) {
+	if opts.Pool == nil {
+		opts.Pool = &PoolConfig{}
+	}
+	p(opts.Pool)
+}
+
+// WithMaxIdleConns is the max idle connections of all hosts , 0 is no limit
+func WithMaxIdleConns(n int) ClientOption {
+	return PoolOption(func(pool *PoolConfig) { pool.MaxIdleConns = n })
+}
+
+// WithMaxIdleConnsPerHost is the max idle connections of each host , default is http.DefaultMaxIdleConnsPerHost (2)
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return PoolOption(func(pool *PoolConfig) { pool.MaxIdleConnsPerHost = n })
+}
+
+// WithMaxConnsPerHost is the max connections (dialing , active and idle) of each host , 0 is no limit
+func WithMaxConnsPerHost(n int) ClientOption {
+	return PoolOption(func(pool *PoolConfig) { pool.MaxConnsPerHost = n })
+}
+
+// WithIdleConnTimeout is close the idle connection after the timeout
+func WithIdleConnTimeout(timeout time.Duration) ClientOption {
+	return PoolOption(func(pool *PoolConfig) { pool.IdleConnTimeout = timeout })
+}
+
+// WithDialTimeout is the timeout of tcp connect
+func WithDialTimeout(timeout time.Duration) ClientOption {
+	return PoolOption(func(pool *PoolConfig) { pool.DialTimeout = timeout })
+}
+
+// WithKeepAlive is the tcp keep-alive period , negative is disable it
+func WithKeepAlive(keepAlive time.Duration) ClientOption {
+	return PoolOption(func(pool *PoolConfig) { pool.KeepAlive = keepAlive })
+}
+
+// WithTLSHandshakeTimeout is the timeout of tls handshake
+func WithTLSHandshakeTimeout(timeout time.Duration) ClientOption {
+	return PoolOption(func(pool *PoolConfig) { pool.TLSHandshakeTimeout = timeout })
+}
+
+// WithResponseHeaderTimeout is the timeout of waiting response header after the request is written
+func WithResponseHeaderTimeout(timeout time.Duration) ClientOption {
+	return PoolOption(func(pool *PoolConfig) { pool.ResponseHeaderTimeout = timeout })
+}
+
+// WithForceAttemptHTTP2 is try http2 even if custom dial or tls config is set
+func WithForceAttemptHTTP2(force bool) ClientOption {
+	return PoolOption(func(pool *PoolConfig) { pool.ForceAttemptHTTP2 = &force })
+}
+
+// applyPool is apply the pool config on the cloned transport
+func applyPool(t *http.Transport, pool *PoolConfig) {
+	if pool.MaxIdleConns != 0 {
+		t.MaxIdleConns = pool.MaxIdleConns
+	}
+	if pool.MaxIdleConnsPerHost != 0 {
+		t.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	}
+	if pool.MaxConnsPerHost != 0 {
+		t.MaxConnsPerHost = pool.MaxConnsPerHost
+	}
+	if pool.IdleConnTimeout != 0 {
+		t.IdleConnTimeout = pool.IdleConnTimeout
+	}
+	if pool.TLSHandshakeTimeout != 0 {
+		t.TLSHandshakeTimeout = pool.TLSHandshakeTimeout
+	}
+	if pool.ResponseHeaderTimeout != 0 {
+		t.ResponseHeaderTimeout = pool.ResponseHeaderTimeout
+	}
+	if pool.ForceAttemptHTTP2 != nil {
+		t.ForceAttemptHTTP2 = *pool.ForceAttemptHTTP2
+	}
+	if pool.DialTimeout != 0 || pool.KeepAlive != 0 {
+		// same as the dialer of http.DefaultTransport
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if pool.DialTimeout != 0 {
+			dialer.Timeout = pool.DialTimeout
+		}
+		if pool.KeepAlive != 0 {
+			dialer.KeepAlive = pool.KeepAlive
+		}
+		t.DialContext = dialer.DialContext
+	}
+}
+
+// HostStats is the connections of a host (host:port) , the host is the proxy if the request is sent by proxy
+type HostStats struct {
+	Open   int // connections dialed and not closed , only counted if the Transport is cloned by options
+	Active int // requests in flight , the response body is not closed
+	Idle   int // Open - Active
+}
+
+// poolStats is count the open connections by dialer and active requests by send ,
+// the methods are safe for nil , eg: the Client is not built by NewClient
+type poolStats struct {
+	mu     sync.Mutex
+	open   map[string]int
+	active map[string]int
+	dialed bool                                  // the dialer of transport is tracked
+	proxy  func(*http.Request) (*url.URL, error) // the proxy of transport , the dialer connect to it
+}
+
+func newPoolStats() *poolStats {
+	return &poolStats{
+		open:   make(map[string]int),
+		active: make(map[string]int),
+	}
+}
+
+func (s *poolStats) add(m map[string]int, host string, delta int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m[host] += delta
+	if m[host] <= 0 {
+		delete(m, host)
+	}
+}
+
+// trackDial is wrap the DialContext of transport to count the open connections
+func (s *poolStats) trackDial(t *http.Transport) {
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	s.dialed = true
+	s.proxy = t.Proxy
+	t.DialContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		s.add(s.open, addr, 1)
+		return &trackedConn{Conn: conn, onClose: func() { s.add(s.open, addr, -1) }}, nil
+	}
+}
+
+// startRequest is count the request as active , the host is returned for trackRequest
+func (s *poolStats) startRequest(req *http.Request) string {
+	if s == nil {
+		return ""
+	}
+	host := s.host(req)
+	s.add(s.active, host, 1)
+	return host
+}
+
+// trackRequest is count the request as active until the response body is closed or read to EOF
+func (s *poolStats) trackRequest(host string, resp *http.Response, err error) *http.Response {
+	if s == nil {
+		return resp
+	}
+	if err != nil || resp == nil || resp.Body == nil {
+		s.add(s.active, host, -1)
+		return resp
+	}
+	resp.Body = &trackedBody{ReadCloser: resp.Body, onDone: func() { s.add(s.active, host, -1) }}
+	return resp
+}
+
+// host is the address which the dialer connect to , same as the key of open connections
+func (s *poolStats) host(req *http.Request) string {
+	if s.proxy != nil {
+		if u, err := s.proxy(req); err == nil && u != nil {
+			return proxyHost(u)
+		}
+	}
+	return canonicalHost(req)
+}
+
+func (s *poolStats) snapshot() map[string]HostStats {
+	hosts := make(map[string]HostStats)
+	if s == nil {
+		return hosts
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for host, n := range s.open {
+		stats := hosts[host]
+		stats.Open = n
+		hosts[host] = stats
+	}
+	for host, n := range s.active {
+		stats := hosts[host]
+		stats.Active = n
+		hosts[host] = stats
+	}
+	for host, stats := range hosts {
+		if s.dialed && stats.Open > stats.Active {
+			stats.Idle = stats.Open - stats.Active
+		}
+		hosts[host] = stats
+	}
+	return hosts
+}
+
+// Stats is the live connection pool stats of each host:port ,
+// the open and idle connections are only counted if the Transport is cloned by tls , proxy or pool options
+func (client *Client) Stats() map[string]HostStats {
+	return client.stats.snapshot()
+}
+
+// canonicalHost is host:port of request url
+func canonicalHost(req *http.Request) string {
+	host, port := req.URL.Hostname(), req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// proxyHost is host:port of proxy url , the default port is same as http.Transport
+func proxyHost(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "socks5":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	onClose func()
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(c.onClose)
+	return c.Conn.Close()
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	once   sync.Once
+	onDone func()
+}
+
+func (b *trackedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.onDone)
+	}
+	return n, err
+}
+
+func (b *trackedBody) Close() error {
+	b.once.Do(b.onDone)
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/zengzhengrong/request/request"
+)
+
+type DownloadProgressOption struct {
+	fn       request.ProgressFunc
+	interval time.Duration
+}
+
+func (d DownloadProgressOption) apply(opts *ClientOptions) {
+	if d.fn == nil {
+		opts.downloadProgress = nil
+		return
+	}
+	opts.downloadProgress = &d
+}
+
+// WithDownloadProgress is report the progress of reading every response body , at most once per interval ,
+// the default interval is request.DefaultProgressInterval , the total is -1 if the Content-Length is unknown
+func WithDownloadProgress(fn request.ProgressFunc, interval ...time.Duration) ClientOption {
+	d := DownloadProgressOption{fn: fn}
+	if len(interval) > 0 {
+		d.interval = interval[0]
+	}
+	return d
+}
+
+// trackDownload is wrap the body of response to report the download progress
+func (client *Client) trackDownload(resp *http.Response) {
+	d := client.Opts.downloadProgress
+	if d == nil || resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+	resp.Body = request.TrackProgress(resp.Body, resp.ContentLength, d.fn, d.interval)
+}
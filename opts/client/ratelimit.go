@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/zengzhengrong/request/request"
+)
+
+type RateLimitOption struct {
+	host  string // empty is global
+	rps   float64
+	burst int
+}
+
+func (r RateLimitOption) apply(opts *ClientOptions) {
+	if opts.rateLimiter == nil {
+		opts.rateLimiter = &rateLimiter{
+			hosts:       make(map[string]*tokenBucket),
+			pausedUntil: make(map[string]time.Time),
+		}
+	}
+	bucket := newTokenBucket(r.rps, r.burst)
+	if r.host == "" {
+		opts.rateLimiter.global = bucket
+		return
+	}
+	opts.rateLimiter.hosts[r.host] = bucket
+}
+
+// WithRateLimit is limit the requests of all hosts by token bucket , rps tokens per second and burst tokens at most ,
+// the request wait for the token before it is sent , a 429 response with Retry-After pause the host until then
+func WithRateLimit(rps float64, burst int) ClientOption {
+	return RateLimitOption{rps: rps, burst: burst}
+}
+
+// WithHostRateLimit is limit the requests of the host (eg: api.x.com or api.x.com:8443) , can be used with WithRateLimit
+func WithHostRateLimit(host string, rps float64, burst int) ClientOption {
+	return RateLimitOption{host: host, rps: rps, burst: burst}
+}
+
+// tokenBucket is refill rate tokens per second up to burst , a request take one token
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait is reserve a token and wait until it is available , the token is given back if ctx is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		if b.rate <= 0 {
+			b.tokens++
+			b.mu.Unlock()
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if err := sleep(ctx, delay); err != nil {
+		b.release()
+		return err
+	}
+	return nil
+}
+
+// release is give back the token taken by wait , eg: the request is canceled before sent
+func (b *tokenBucket) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+// sleep is wait the duration or ctx done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type rateLimiter struct {
+	global *tokenBucket
+	hosts  map[string]*tokenBucket // host or host:port >> bucket
+
+	mu          sync.Mutex
+	pausedUntil map[string]time.Time // host:port >> the Retry-After of 429
+}
+
+func (l *rateLimiter) hostBucket(u *url.URL) *tokenBucket {
+	if b, ok := l.hosts[u.Host]; ok {
+		return b
+	}
+	return l.hosts[u.Hostname()]
+}
+
+func (l *rateLimiter) pause(host string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until := time.Now().Add(d)
+	if until.After(l.pausedUntil[host]) {
+		l.pausedUntil[host] = until
+	}
+}
+
+// waitPause is wait until the host is not paused by 429
+func (l *rateLimiter) waitPause(ctx context.Context, host string) error {
+	for {
+		l.mu.Lock()
+		until, ok := l.pausedUntil[host]
+		if ok && !time.Now().Before(until) {
+			delete(l.pausedUntil, host)
+		}
+		l.mu.Unlock()
+		d := time.Until(until)
+		if !ok || d <= 0 {
+			return nil
+		}
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *rateLimiter) middleware(next Handler) Handler {
+	return func(r *request.Request) (*http.Response, error) {
+		ctx := r.HttpReq.Context()
+		host := canonicalHost(r.HttpReq)
+		if err := l.waitPause(ctx, host); err != nil {
+			return nil, err
+		}
+		b := l.hostBucket(r.HttpReq.URL)
+		if b != nil {
+			if err := b.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if l.global != nil {
+			if err := l.global.wait(ctx); err != nil {
+				// the request is not sent , the host token is not used
+				if b != nil {
+					b.release()
+				}
+				return nil, err
+			}
+		}
+		resp, err := next(r)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			if d, ok := retryAfter(resp); ok {
+				l.pause(host, d)
+			}
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/request"
+)
+
+const (
+	DefaultRetryMaxAttempts     = 3
+	DefaultRetryInitialInterval = 100 * time.Millisecond
+	DefaultRetryMaxInterval     = 10 * time.Second
+	DefaultRetryMultiplier      = 2.0
+)
+
+// DefaultRetryStatusCodes is the status codes which are retried when RetryPolicy.RetryStatusCodes is nil
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy is describe when a request should be sent again and how long to wait between attempts
+// the zero value of each field use the default value
+type RetryPolicy struct {
+	MaxAttempts      int           // total attempts include the first one
+	MaxElapsedTime   time.Duration // stop retrying once this duration is exceeded , 0 is never stop
+	InitialInterval  time.Duration // backoff base of the first retry
+	MaxInterval      time.Duration // backoff cap of every retry
+	Multiplier       float64       // backoff growth factor between attempts
+	RetryStatusCodes []int         // retry if the response status code is one of them
+}
+
+type RetryOption RetryPolicy
+
+func (r RetryOption) apply(opts *ClientOptions) {
+	policy := RetryPolicy(r)
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = DefaultRetryInitialInterval
+	}
+	if policy.MaxInterval <= 0 {
+		policy.MaxInterval = DefaultRetryMaxInterval
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = DefaultRetryMultiplier
+	}
+	if policy.RetryStatusCodes == nil {
+		policy.RetryStatusCodes = DefaultRetryStatusCodes
+	}
+	opts.Retry = &policy
+}
+
+// WithRetry is retry on network error and RetryStatusCodes with exponential backoff and full jitter,
+// Retry-After header of response is honored, request body is replay from ReqOptions.RawBody
+func WithRetry(policy ...RetryPolicy) ClientOption {
+	var p RetryPolicy
+	if len(policy) > 0 {
+		p = policy[0]
+	}
+	return RetryOption(p)
+}
+
+func (p *RetryPolicy) retryable(r *request.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// the breaker is open , retry would fail fast again
+		if errors.Is(err, config.CircuitOpenError) {
+			return false
+		}
+		// do not retry the request which is canceled by caller
+		return r.HttpReq.Context().Err() == nil
+	}
+	for _, code := range p.RetryStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff is full jitter exponential backoff , random between 0 and min(MaxInterval, InitialInterval*Multiplier^attempt)
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialInterval) * math.Pow(p.Multiplier, float64(attempt))
+	if d > float64(p.MaxInterval) {
+		d = float64(p.MaxInterval)
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// retryAfter parse Retry-After header , it could be delay seconds or a http date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// discard is read a little of body to reuse the connection and close it
+func discard(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+}
+
+func (p *RetryPolicy) middleware(next Handler) Handler {
+	return func(r *request.Request) (*http.Response, error) {
+		return p.do(r, next)
+	}
+}
+
+func (p *RetryPolicy) do(r *request.Request, send Handler) (*http.Response, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		resp, err := send(r)
+		if attempt >= p.MaxAttempts || !p.retryable(r, resp, err) {
+			return resp, err
+		}
+		wait, ok := retryAfter(resp)
+		if !ok {
+			wait = p.backoff(attempt - 1)
+		}
+		if p.MaxElapsedTime > 0 && time.Since(start)+wait > p.MaxElapsedTime {
+			return resp, err
+		}
+		// can not replay the body , return the last result
+		if rerr := r.Rewind(); rerr != nil {
+			return resp, err
+		}
+		discard(resp)
+
+		ctx := r.HttpReq.Context()
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
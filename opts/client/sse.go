@@ -0,0 +1,244 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/request"
+)
+
+// Event is a server-sent event
+type Event struct {
+	ID    string        // the last event id , it is sent as Last-Event-ID when reconnecting
+	Event string        // the event type , "message" if the server does not set
+	Data  string        // the data lines joined by "\n"
+	Retry time.Duration // the reconnection delay set by this event , 0 if not set
+}
+
+// EventStream is the events received by Client.SSE
+type EventStream struct {
+	events chan Event
+	cancel context.CancelFunc
+	done   chan struct{}
+	closed int32
+	err    error
+
+	client *Client
+	url    string
+	opts   []request.ReqOption
+	lastID string
+	retry  time.Duration
+}
+
+// SSE is subscribe the text/event-stream of url , the request is GET and built by opts ,
+// the stream is reconnected with Last-Event-ID after the retry delay of server if the connection is lost ,
+// it stop if ctx is done , Close is called , the server response 204 or a status other than 200 ,
+// or the request can not be built or sent (eg: invalid path params , signer error) ,
+// the Timeout of client is not used because the stream is long lived , use ctx to limit it
+func (client *Client) SSE(ctx context.Context, url string, opts ...request.ReqOption) *EventStream {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &EventStream{
+		events: make(chan Event),
+		cancel: cancel,
+		done:   make(chan struct{}),
+		client: client.withoutTimeout(),
+		url:    url,
+		opts:   append(opts[:len(opts):len(opts)], request.WithStream()),
+		retry:  config.DefaultSSERetry,
+	}
+	go func() {
+		defer close(s.done)
+		defer close(s.events)
+		err := s.run(ctx)
+		if atomic.LoadInt32(&s.closed) == 1 {
+			err = nil
+		}
+		s.err = err
+	}()
+	return s
+}
+
+// Events is the channel of events , it is closed when the stream stop
+func (s *EventStream) Events() <-chan Event {
+	return s.events
+}
+
+// Err is the error which stop the stream , it should be called after Events is closed ,
+// nil if the server end the stream by 204 or Close is called
+func (s *EventStream) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Close is stop the stream and wait for the connection closed
+func (s *EventStream) Close() error {
+	atomic.StoreInt32(&s.closed, 1)
+	s.cancel()
+	<-s.done
+	return s.err
+}
+
+func (s *EventStream) run(ctx context.Context) error {
+	if s.client.err != nil {
+		return s.client.err
+	}
+	for {
+		r, err := s.newRequest(ctx)
+		if err != nil {
+			// reconnecting does not fix it
+			return err
+		}
+		resp, err := s.client.Do(r)
+		if err != nil && !reconnectable(err) {
+			return err
+		}
+		if err == nil {
+			if resp.StatusCode == http.StatusNoContent {
+				resp.Body.Close()
+				return nil
+			}
+			if resp.StatusCode != http.StatusOK {
+				return statusError(resp)
+			}
+			if ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); ct != "text/event-stream" {
+				resp.Body.Close()
+				return fmt.Errorf("%w: unexpected Content-Type %q", config.EventStreamError, resp.Header.Get("Content-Type"))
+			}
+			err = s.read(ctx, resp.Body)
+			resp.Body.Close()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// the connection is lost or closed by server , reconnect after retry delay
+		if err := sleep(ctx, s.retry); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *EventStream) newRequest(ctx context.Context) (*request.Request, error) {
+	r, err := s.client.newRequest(ctx, http.MethodGet, s.url, s.opts)
+	if err != nil {
+		return nil, err
+	}
+	r.HttpReq.Header.Set("Accept", "text/event-stream")
+	r.HttpReq.Header.Set("Cache-Control", "no-cache")
+	if s.lastID != "" {
+		r.HttpReq.Header.Set("Last-Event-ID", s.lastID)
+	}
+	return r, nil
+}
+
+// reconnectable is the error of connection (returned by http.Client) or the open circuit breaker ,
+// the other errors are returned by middlewares before sending , eg: signer
+func reconnectable(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, config.CircuitOpenError)
+}
+
+// read is parse the stream until EOF , see https://html.spec.whatwg.org/multipage/server-sent-events.html
+func (s *EventStream) read(ctx context.Context, body io.Reader) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 4096), config.DefaultSSEMaxLineSize)
+	scanner.Split(scanEventLines())
+
+	var (
+		event Event
+		data  strings.Builder
+		id    = s.lastID // the id of an incomplete event is not used to reconnect
+		first = true
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+		if line == "" {
+			// dispatch the event , the event without data is ignored
+			s.lastID = id
+			if data.Len() > 0 {
+				event.ID = s.lastID
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				if event.Event == "" {
+					event.Event = "message"
+				}
+				select {
+				case s.events <- event:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			event = Event{}
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// comment
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				id = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 32); err == nil {
+				event.Retry = time.Duration(ms) * time.Millisecond
+				s.retry = event.Retry
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// scanEventLines is split the lines end with "\r\n" , "\n" or "\r"
+func scanEventLines() bufio.SplitFunc {
+	skipLF := false
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if skipLF && len(data) > 0 {
+			// the "\n" of "\r\n" which is split by the last read
+			skipLF = false
+			if data[0] == '\n' {
+				return 1, nil, nil
+			}
+		}
+		if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+			if data[i] == '\n' {
+				return i + 1, data[:i], nil
+			}
+			if i+1 < len(data) {
+				if data[i+1] == '\n' {
+					return i + 2, data[:i], nil
+				}
+				return i + 1, data[:i], nil
+			}
+			skipLF = true
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSOption is modify the tls config of the cloned transport
+type TLSOption func(cfg *tls.Config) error
+
+func (t TLSOption) apply(opts *ClientOptions) {
+	opts.tlsOptions = append(opts.tlsOptions, t)
+}
+
+// WithClientCert is the client certificate for mutual tls
+func WithClientCert(certPEM []byte, keyPEM []byte) ClientOption {
+	return TLSOption(func(cfg *tls.Config) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("load client cert: %w", err)
+		}
+		cfg.Certificates = append(cfg.Certificates, cert)
+		return nil
+	})
+}
+
+// WithClientCertFile is the client certificate files for mutual tls ,
+// the files are reloaded in the next tls handshake once they are modified (eg: renewed by cert-manager)
+func WithClientCertFile(certFile string, keyFile string) ClientOption {
+	return TLSOption(func(cfg *tls.Config) error {
+		reloader := &certReloader{certFile: certFile, keyFile: keyFile}
+		if _, err := reloader.load(); err != nil {
+			return err
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.load()
+		}
+		return nil
+	})
+}
+
+// WithRootCAs is append the ca certificates of pem files to system cert pool , to verify the server of private ca
+func WithRootCAs(pemFiles ...string) ClientOption {
+	return TLSOption(func(cfg *tls.Config) error {
+		pool := cfg.RootCAs
+		if pool == nil {
+			pool, _ = x509.SystemCertPool()
+		}
+		if pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, file := range pemFiles {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("load root ca: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(b) {
+				return fmt.Errorf("load root ca: no certificate found in %s", file)
+			}
+		}
+		cfg.RootCAs = pool
+		return nil
+	})
+}
+
+// WithTLSMinVersion is the minimum tls version , eg: tls.VersionTLS12
+func WithTLSMinVersion(version uint16) ClientOption {
+	return TLSOption(func(cfg *tls.Config) error {
+		cfg.MinVersion = version
+		return nil
+	})
+}
+
+// certReloader is reload the key pair if the modify time of files changed
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (c *certReloader) load() (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	modTime, err := latestModTime(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			// the files may be in the middle of renew , keep using the old one
+			return c.cert, nil
+		}
+		return nil, fmt.Errorf("load client cert: %w", err)
+	}
+	if c.cert != nil && modTime.Equal(c.modTime) {
+		return c.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			return c.cert, nil
+		}
+		return nil, fmt.Errorf("load client cert: %w", err)
+	}
+	c.cert = &cert
+	c.modTime = modTime
+	return c.cert, nil
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
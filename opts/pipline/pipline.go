@@ -78,6 +78,8 @@ func ctxsetfinish(ctx context.Context) context.Context {
 	return context.WithValue(ctx, piplineCtxValueKey, v)
 }
 
+// Result is run the pipline , the ctx is passed to every In and Out func ,
+// use it (eg: client.GETCtx) to cancel the requests of pipline
 func (p *PipLine) Result(ctxs ...context.Context) response.Response {
 	if len(ctxs) > 0 {
 		p.Ctx = ctxs[0]
@@ -90,16 +92,18 @@ func (p *PipLine) Result(ctxs ...context.Context) response.Response {
 	}
 
 	// process ins
-	g, ctx := errgroup.WithContext(ctx)
 	if p.Parall {
-
+		// the errgroup ctx is canceled once any In failed or all of Ins are done
+		g, gctx := errgroup.WithContext(ctx)
 		for index, fn := range p.Ins {
-			ctxmap["current_request_index"] = index
-			ctx = context.WithValue(ctx, piplineCtxValueKey, ctxmap)
+			fnctx := context.WithValue(gctx, piplineCtxValueKey, map[string]any{
+				"current_request_index": index,
+				"is_request_finish":     false,
+			})
 			fn := fn // reassignment fn var
 			index := index
 			g.Go(func() error {
-				resp, err := fn(ctx, p.PipLineClient)
+				resp, err := fn(fnctx, p.PipLineClient)
 				if err != nil {
 					err = fmt.Errorf("ins[%v]:[%w]", index, err)
 					return err
@@ -111,7 +115,8 @@ func (p *PipLine) Result(ctxs ...context.Context) response.Response {
 		if err := g.Wait(); err != nil {
 			return response.Response{Err: err}
 		}
-		ctxsetfinish(ctx)
+		ctxmap["current_request_index"] = len(p.Ins) - 1
+		ctx = ctxsetfinish(context.WithValue(ctx, piplineCtxValueKey, ctxmap))
 	} else {
 		for index, fn := range p.Ins {
 			ctxmap["current_request_index"] = index
@@ -123,11 +128,11 @@ func (p *PipLine) Result(ctxs ...context.Context) response.Response {
 			}
 			insRes[index] = resp
 			if index == len(p.Ins)-1 {
-				ctxsetfinish(ctx)
+				ctx = ctxsetfinish(ctx)
 			}
 		}
 	}
-	// process out
+	// process out , use the pipline ctx because the errgroup ctx is already canceled
 	resp := p.Out(ctx, p.PipLineClient, insRes...)
 	return resp
 }
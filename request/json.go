@@ -0,0 +1,41 @@
+package request
+
+import (
+	"bytes"
+
+	"github.com/zengzhengrong/request/codec"
+	"github.com/zengzhengrong/request/config"
+)
+
+// JSONMarshal is the encoder of WithJSON , replace it to use other json library ,
+// the default is encoding/json without html escaping
+var JSONMarshal = codec.JSON.Marshal
+
+type EncodedOption struct {
+	BodyOption
+	contentType string
+}
+
+func (e EncodedOption) apply(opts *ReqOptions) {
+	e.BodyOption.apply(opts)
+	opts.ContentType = e.contentType
+}
+
+// WithJSON is marshal the value by JSONMarshal as body and set json Content-Type ,
+// the marshalled bytes are kept in RawBody , so the body can be replayed by Clone and retry
+func WithJSON(v any) ReqOption {
+	return encoded(JSONMarshal, config.JsonContectType, v)
+}
+
+// WithEncoded is WithJSON by the codec , eg: codec.XML , codec.YAML , codec.Form , codec.MsgPack
+func WithEncoded(c codec.Codec, v any) ReqOption {
+	return encoded(c.Marshal, c.ContentType(), v)
+}
+
+func encoded(marshal func(v any) ([]byte, error), contentType string, v any) ReqOption {
+	b, err := marshal(v)
+	if err != nil {
+		return EncodedOption{BodyOption: BodyOption{err: err}}
+	}
+	return EncodedOption{BodyOption: BodyOption{Reader: bytes.NewReader(b), raw: b}, contentType: contentType}
+}
@@ -0,0 +1,293 @@
+package request
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/zengzhengrong/request/config"
+)
+
+// PartOption is change the header of a part , eg: content type
+type PartOption func(header textproto.MIMEHeader)
+
+// WithPartContentType is set Content-Type of the part
+func WithPartContentType(contentType string) PartOption {
+	return func(header textproto.MIMEHeader) {
+		header.Set("Content-Type", contentType)
+	}
+}
+
+// WithPartHeader is set a header of the part
+func WithPartHeader(key string, value string) PartOption {
+	return func(header textproto.MIMEHeader) {
+		header.Set(key, value)
+	}
+}
+
+// Multipart is the builder of multipart/form-data body , the parts are streamed by io.Pipe when the request is sent ,
+// so the files are not read into memory , see WithMultipart
+type Multipart struct {
+	boundary string
+	parts    []*part
+	err      error
+}
+
+type part struct {
+	header textproto.MIMEHeader
+	size   int64 // -1 is unknown
+	open   func() (io.ReadCloser, error)
+	replay func() error // nil if the part can always be opened again
+}
+
+// NewMultipart is a empty Multipart with random boundary
+func NewMultipart() *Multipart {
+	return &Multipart{boundary: multipart.NewWriter(io.Discard).Boundary()}
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func formDataHeader(fieldname string, filename string, opts []PartOption) textproto.MIMEHeader {
+	header := make(textproto.MIMEHeader)
+	disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(fieldname))
+	if filename != "" {
+		disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(filename))
+		contentType := mime.TypeByExtension(filepath.Ext(filename))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set("Content-Type", contentType)
+	}
+	header.Set("Content-Disposition", disposition)
+	for _, o := range opts {
+		o(header)
+	}
+	return header
+}
+
+// Field is add a form field
+func (m *Multipart) Field(name string, value string, opts ...PartOption) *Multipart {
+	m.parts = append(m.parts, &part{
+		header: formDataHeader(name, "", opts),
+		size:   int64(len(value)),
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(value)), nil
+		},
+	})
+	return m
+}
+
+// File is add the file of path , the file is opened when the body is sent ,
+// the filename is the base of path and Content-Type is guessed by the extension
+func (m *Multipart) File(fieldname string, path string, opts ...PartOption) *Multipart {
+	info, err := os.Stat(path)
+	if err != nil {
+		m.setErr(err)
+		return m
+	}
+	m.parts = append(m.parts, &part{
+		header: formDataHeader(fieldname, filepath.Base(path), opts),
+		size:   info.Size(),
+		open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	})
+	return m
+}
+
+// Reader is add a file of reader , size is the bytes will be read or -1 if unknown ,
+// the size of *bytes.Reader , *strings.Reader , *bytes.Buffer and *os.File is detected if it is -1 ,
+// the reader is read from the current offset again on rewind if it is a io.Seeker
+func (m *Multipart) Reader(fieldname string, filename string, r io.Reader, size int64, opts ...PartOption) *Multipart {
+	m.parts = append(m.parts, readerPart(formDataHeader(fieldname, filename, opts), r, size))
+	return m
+}
+
+// Part is add a part of custom header
+func (m *Multipart) Part(header textproto.MIMEHeader, r io.Reader, size int64) *Multipart {
+	m.parts = append(m.parts, readerPart(header, r, size))
+	return m
+}
+
+func readerPart(header textproto.MIMEHeader, r io.Reader, size int64) *part {
+	if size < 0 {
+		size = readerSize(r)
+	}
+	p := &part{header: header, size: size}
+	seeker, ok := r.(io.Seeker)
+	var start int64
+	if ok {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			ok = false
+		}
+	}
+	var opened int32
+	p.open = func() (io.ReadCloser, error) {
+		if atomic.SwapInt32(&opened, 1) == 1 {
+			if err := p.replay(); err != nil {
+				return nil, err
+			}
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("%w: %v", config.ReplayBodyError, err)
+			}
+		}
+		return io.NopCloser(r), nil
+	}
+	p.replay = func() error {
+		if !ok && atomic.LoadInt32(&opened) == 1 {
+			return fmt.Errorf("%w: %T is not a io.Seeker", config.ReplayBodyError, r)
+		}
+		return nil
+	}
+	return p
+}
+
+// readerSize is the remaining size of known readers , -1 if unknown
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	}
+	return -1
+}
+
+func (m *Multipart) setErr(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+// FormDataContentType is the Content-Type with boundary
+func (m *Multipart) FormDataContentType() string {
+	return "multipart/form-data; boundary=" + m.boundary
+}
+
+// Len is the size of body , -1 if the size of a part is unknown
+func (m *Multipart) Len() int64 {
+	counter := &countWriter{}
+	w := multipart.NewWriter(counter)
+	w.SetBoundary(m.boundary)
+	for _, p := range m.parts {
+		if p.size < 0 {
+			return -1
+		}
+		w.CreatePart(p.header)
+		counter.n += p.size
+	}
+	w.Close()
+	return counter.n
+}
+
+type countWriter struct{ n int64 }
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// rewind is check whether the body can be sent again
+func (m *Multipart) rewind() error {
+	for _, p := range m.parts {
+		if p.replay == nil {
+			continue
+		}
+		if err := p.replay(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// body is the streaming body , the pipe is not started until the first Read
+func (m *Multipart) body() io.ReadCloser {
+	return &lazyBody{open: func() io.ReadCloser {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(m.writeTo(pw))
+		}()
+		return pr
+	}}
+}
+
+func (m *Multipart) writeTo(w io.Writer) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(m.boundary); err != nil {
+		return err
+	}
+	for _, p := range m.parts {
+		pw, err := mw.CreatePart(p.header)
+		if err != nil {
+			return err
+		}
+		rc, err := p.open()
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(pw, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		// the Content-Length is already sent
+		if p.size >= 0 && n != p.size {
+			return fmt.Errorf("multipart part %s: size is %d , but %d bytes are read", p.header.Get("Content-Disposition"), p.size, n)
+		}
+	}
+	return mw.Close()
+}
+
+type lazyBody struct {
+	open func() io.ReadCloser
+	rc   io.ReadCloser
+}
+
+func (b *lazyBody) Read(p []byte) (int, error) {
+	if b.rc == nil {
+		b.rc = b.open()
+	}
+	return b.rc.Read(p)
+}
+
+func (b *lazyBody) Close() error {
+	if b.rc == nil {
+		return nil
+	}
+	return b.rc.Close()
+}
+
+type MultipartOption struct{ m *Multipart }
+
+func (o MultipartOption) apply(opts *ReqOptions) {
+	if o.m.err != nil {
+		opts.setErr(o.m.err)
+		return
+	}
+	opts.Body = o.m.body()
+	opts.RawBody = o.m
+	opts.ContentType = o.m.FormDataContentType()
+	opts.ContentLength = o.m.Len()
+}
+
+// WithMultipart is stream the multipart/form-data body , Content-Length is set if the sizes of all parts are known ,
+// otherwise the body is chunked , eg:
+// request.WithMultipart(request.NewMultipart().Field("name", "x").File("file", "/tmp/a.png"))
+func WithMultipart(m *Multipart) ReqOption {
+	return MultipartOption{m}
+}
@@ -0,0 +1,139 @@
+package request
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/zengzhengrong/request/config"
+)
+
+type PathParamsOption map[string]string
+
+func (p PathParamsOption) apply(opts *ReqOptions) {
+	if opts.PathParams == nil {
+		opts.PathParams = make(map[string]string, len(p))
+	}
+	for k, v := range p {
+		opts.PathParams[k] = v
+	}
+}
+
+// WithPathParams is replace the {name} placeholders of url path with url.PathEscape(value) ,
+// eg: /users/{id}/orders/{orderID} , the request is failed if a placeholder has no param or a param is not used ,
+// the url before replaced is kept in ReqOptions.PathTemplate
+func WithPathParams(params map[string]string) ReqOption {
+	return PathParamsOption(params)
+}
+
+// WithPathParamsStruct is WithPathParams by the fields of struct which has path tag ,
+// eg: ID int `path:"id"` , the field without path tag is ignored
+func WithPathParamsStruct(v any) ReqOption {
+	params, err := structPathParams(v)
+	if err != nil {
+		return errOption{err}
+	}
+	return PathParamsOption(params)
+}
+
+// errOption is keep the error of building option , it is returned by NewReuqest
+type errOption struct{ err error }
+
+func (e errOption) apply(opts *ReqOptions) {
+	opts.setErr(e.err)
+}
+
+// setErr is keep the first error of options
+func (opts *ReqOptions) setErr(err error) {
+	if opts.err == nil {
+		opts.err = err
+	}
+}
+
+func structPathParams(v any) (map[string]string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("%w: path params is nil", config.PathParamError)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: path params must be a struct , got %T", config.PathParamError, v)
+	}
+	params := make(map[string]string)
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, ok := field.Tag.Lookup("path")
+		if !ok || name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		value, err := cast.ToStringE(rv.Field(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %s: %v", config.PathParamError, field.Name, err)
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+// expandPath is replace the placeholders of url path , the placeholders may be escaped as %7Bname%7D ,
+// return the url and the template which placeholders are {name}
+func expandPath(rawurl string, params map[string]string) (string, string, error) {
+	path, query := rawurl, ""
+	if i := strings.IndexAny(rawurl, "?#"); i >= 0 {
+		path, query = rawurl[:i], rawurl[i:]
+	}
+	path = unescapeBraces(path)
+
+	var (
+		b       strings.Builder
+		used    = make(map[string]bool, len(params))
+		missing []string
+	)
+	rest := path
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", "", fmt.Errorf("%w: unclosed placeholder in %s", config.PathParamError, path)
+		}
+		end += start
+		b.WriteString(rest[:start])
+		name := rest[start+1 : end]
+		value, ok := params[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		used[name] = true
+		b.WriteString(url.PathEscape(value))
+		rest = rest[end+1:]
+	}
+	if len(missing) > 0 {
+		return "", "", fmt.Errorf("%w: missing params %s of %s", config.PathParamError, strings.Join(missing, ","), path)
+	}
+	var unused []string
+	for name := range params {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", "", fmt.Errorf("%w: unused params %s of %s", config.PathParamError, strings.Join(unused, ","), path)
+	}
+	return b.String() + query, path, nil
+}
+
+// unescapeBraces is turn %7B and %7D back to { and } , url.URL.String escape them
+func unescapeBraces(s string) string {
+	return strings.NewReplacer("%7B", "{", "%7b", "{", "%7D", "}", "%7d", "}").Replace(s)
+}
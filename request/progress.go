@@ -0,0 +1,205 @@
+package request
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultProgressInterval is the min interval between two progress reports
+var DefaultProgressInterval = 200 * time.Millisecond
+
+// Progress is the state of a transfer
+type Progress struct {
+	Transferred    int64         // bytes transferred , include the resumed bytes
+	Total          int64         // total bytes , -1 if unknown
+	Elapsed        time.Duration // since the transfer started
+	BytesPerSecond float64       // average throughput of this transfer
+	Done           bool          // the last report
+}
+
+// ProgressFunc is called with the progress , it should return quickly
+type ProgressFunc func(p Progress)
+
+// ProgressTracker is count the transferred bytes and report at most once per interval , safe for concurrent use
+type ProgressTracker struct {
+	fn       ProgressFunc
+	interval time.Duration
+
+	mu          sync.Mutex
+	start       time.Time
+	last        time.Time
+	total       int64
+	resumed     int64
+	transferred int64
+	done        bool
+}
+
+// NewProgressTracker is a tracker with unknown total , DefaultProgressInterval is used if interval <= 0
+func NewProgressTracker(fn ProgressFunc, interval time.Duration) *ProgressTracker {
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	now := time.Now()
+	return &ProgressTracker{fn: fn, interval: interval, start: now, last: now, total: -1}
+}
+
+// SetTotal is set the total bytes , -1 if unknown
+func (t *ProgressTracker) SetTotal(total int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total = total
+}
+
+// Resume is add the bytes transferred before , they are not counted in throughput
+func (t *ProgressTracker) Resume(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resumed += n
+	t.transferred += n
+}
+
+// Add is count the transferred bytes and report if the interval is passed
+func (t *ProgressTracker) Add(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.transferred += n
+	if now := time.Now(); now.Sub(t.last) >= t.interval {
+		t.last = now
+		t.report(now)
+	}
+}
+
+// Finish is the last report , it is reported only once
+func (t *ProgressTracker) Finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return
+	}
+	t.done = true
+	t.report(time.Now())
+}
+
+func (t *ProgressTracker) report(now time.Time) {
+	if t.fn == nil {
+		return
+	}
+	elapsed := now.Sub(t.start)
+	p := Progress{
+		Transferred: t.transferred,
+		Total:       t.total,
+		Elapsed:     elapsed,
+		Done:        t.done,
+	}
+	if elapsed > 0 {
+		p.BytesPerSecond = float64(t.transferred-t.resumed) / elapsed.Seconds()
+	}
+	t.fn(p)
+}
+
+// Reader is count the bytes read from r
+func (t *ProgressTracker) Reader(r io.Reader) io.Reader {
+	return &progressReader{Reader: r, tracker: t}
+}
+
+type progressReader struct {
+	io.Reader
+	tracker *ProgressTracker
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.tracker.Add(int64(n))
+	}
+	return n, err
+}
+
+type UploadProgressOption struct {
+	fn       ProgressFunc
+	interval time.Duration
+}
+
+func (u UploadProgressOption) apply(opts *ReqOptions) {
+	if u.fn == nil {
+		opts.uploadProgress = nil
+		return
+	}
+	opts.uploadProgress = &u
+}
+
+// WithUploadProgress is report the progress of sending request body , at most once per interval ,
+// the default interval is DefaultProgressInterval , the total is -1 if the body size is unknown ,
+// the progress start again if the body is sent again , eg: retry
+func WithUploadProgress(fn ProgressFunc, interval ...time.Duration) ReqOption {
+	u := UploadProgressOption{fn: fn}
+	if len(interval) > 0 {
+		u.interval = interval[0]
+	}
+	return u
+}
+
+// trackUpload is wrap the body and GetBody of request to report the upload progress
+func (opts *ReqOptions) trackUpload(req *http.Request) {
+	if opts.uploadProgress == nil || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	fn, interval, size := opts.uploadProgress.fn, opts.uploadProgress.interval, req.ContentLength
+	req.Body = TrackProgress(req.Body, size, fn, interval)
+	if getBody := req.GetBody; getBody != nil {
+		// the body is sent again by net/http , eg: 307 308 redirect , http2 retry
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return TrackProgress(body, size, fn, interval), nil
+		}
+	}
+}
+
+// TrackProgress is wrap the body to report the progress of reading , the last report is sent at EOF ,
+// size <= 0 is unknown , eg: the body of request or response ,
+// the tracking start at the first read , the body already tracked is returned as is
+func TrackProgress(body io.ReadCloser, size int64, fn ProgressFunc, interval time.Duration) io.ReadCloser {
+	if _, ok := body.(*progressBody); ok {
+		return body
+	}
+	return &progressBody{ReadCloser: body, size: size, fn: fn, interval: interval}
+}
+
+type progressBody struct {
+	io.ReadCloser
+	size     int64
+	fn       ProgressFunc
+	interval time.Duration
+	tracker  *ProgressTracker
+}
+
+func (b *progressBody) Read(p []byte) (int, error) {
+	if b.tracker == nil {
+		// the time before sending is not counted in throughput
+		b.tracker = NewProgressTracker(b.fn, b.interval)
+		if b.size > 0 {
+			b.tracker.SetTotal(b.size)
+		}
+	}
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.tracker.Add(int64(n))
+	}
+	if err == io.EOF {
+		b.tracker.Finish()
+	}
+	return n, err
+}
+
+// untracked is the body without progress , eg: the body is read for signing
+func untracked(body io.ReadCloser) io.ReadCloser {
+	if b, ok := body.(*progressBody); ok {
+		return b.ReadCloser
+	}
+	return body
+}
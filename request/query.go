@@ -0,0 +1,35 @@
+package request
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/zengzhengrong/request/codec"
+	"github.com/zengzhengrong/request/config"
+)
+
+// WithQueryValues is add the query of url.Values , a key could have many values
+func WithQueryValues(values url.Values) ReqOption {
+	return QueryOption(values.Encode())
+}
+
+// WithQueryStruct is add the query of struct fields by EncodeQuery
+func WithQueryStruct(v any) ReqOption {
+	values, err := EncodeQuery(v)
+	if err != nil {
+		return errOption{err}
+	}
+	return WithQueryValues(values)
+}
+
+// EncodeQuery is encode the struct to url.Values by query tag , eg: `query:"name,omitempty"` ,
+// the field without tag use the field name , "-" is ignored , omitempty skip the zero value ,
+// slice and array is many values of a key , time.Time is RFC3339 , nil pointer is skipped ,
+// the embedded struct without tag is flattened
+func EncodeQuery(v any) (url.Values, error) {
+	values, err := codec.EncodeValues(v, "query")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", config.QueryEncodeError, err)
+	}
+	return values, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -30,6 +31,17 @@ type ReqOptions struct {
 	RawBody     any
 	Query       string
 	Context     context.Context
+	Signer      Signer `json:"-"`
+	// ContentLength is the body size of streaming body , eg: WithMultipart , 0 or -1 is unknown or detected by Body
+	ContentLength int64
+	// PathTemplate is the url before path params are replaced , eg: for metrics label
+	PathTemplate string
+	PathParams   map[string]string
+	// Stream is the response body is a stream , eg: server-sent events , it is not read by debug
+	Stream bool
+
+	uploadProgress *UploadProgressOption
+	err            error // the first error of options , returned by NewReuqest
 }
 type ReqOption interface {
 	apply(*ReqOptions)
@@ -40,6 +52,7 @@ type HeaderOption map[string]string
 type BodyOption struct {
 	io.Reader
 	raw any
+	err error
 }
 type QueryOption string
 type ContextOption struct{ context.Context }
@@ -56,16 +69,29 @@ func (h HeaderOption) apply(opts *ReqOptions) {
 	opts.Header = h
 }
 func (q QueryOption) apply(opts *ReqOptions) {
-	opts.Query = string(q)
-	if strings.Contains(opts.Url, "?") {
-		opts.Url = opts.Url + "&" + string(q)
+	if q == "" {
+		return
+	}
+	if opts.Query == "" {
+		opts.Query = string(q)
 	} else {
+		opts.Query = opts.Query + "&" + string(q)
+	}
+	switch {
+	case strings.HasSuffix(opts.Url, "?") || strings.HasSuffix(opts.Url, "&"):
+		opts.Url = opts.Url + string(q)
+	case strings.Contains(opts.Url, "?"):
+		opts.Url = opts.Url + "&" + string(q)
+	default:
 		opts.Url = opts.Url + "?" + string(q)
 	}
-
 }
 
 func (b BodyOption) apply(opts *ReqOptions) {
+	if b.err != nil {
+		opts.setErr(b.err)
+		return
+	}
 	opts.Body = b.Reader
 	opts.RawBody = b.raw
 }
@@ -79,9 +105,17 @@ func WithHeader(h map[string]string) ReqOption {
 	return HeaderOption(h)
 }
 
+// WithBody is set the body of io.Reader , string , []byte , or form of map[string]string and map[string]any ,
+// the request is failed if the body is other type , use WithJSON for struct
 func WithBody(body any) ReqOption {
 	var reqBody io.Reader
 	switch v := body.(type) {
+	case nil:
+	case *Multipart:
+		if v.err != nil {
+			return BodyOption{err: v.err}
+		}
+		reqBody = v.body()
 	case io.Reader:
 		reqBody = v
 	case string:
@@ -97,11 +131,17 @@ func WithBody(body any) ReqOption {
 	case map[string]any:
 		val := url.Values{}
 		for k, v := range v {
-			val.Set(k, v.(string))
+			s, err := cast.ToStringE(v)
+			if err != nil {
+				return BodyOption{err: fmt.Errorf("%w: form value of %s: %v", config.BodyTypeError, k, err)}
+			}
+			val.Set(k, s)
 		}
 		reqBody = strings.NewReader(val.Encode())
+	default:
+		return BodyOption{err: fmt.Errorf("%w: unsupported body type %T , use WithJSON", config.BodyTypeError, body)}
 	}
-	return BodyOption{reqBody, body}
+	return BodyOption{Reader: reqBody, raw: body}
 }
 
 func WithQuery(query map[string]string, sortAsc ...bool) ReqOption {
@@ -112,6 +152,17 @@ func WithContext(ctx context.Context) ContextOption {
 	return ContextOption(struct{ context.Context }{ctx})
 }
 
+type StreamOption bool
+
+func (s StreamOption) apply(opts *ReqOptions) {
+	opts.Stream = bool(s)
+}
+
+// WithStream is mark the response body is a stream , the debug mode of client does not read it
+func WithStream() ReqOption {
+	return StreamOption(true)
+}
+
 func (r *Request) String() string {
 	bf := bytes.NewBuffer([]byte{})
 	jsonEncoder := json.NewEncoder(bf)
@@ -124,6 +175,8 @@ func (r *Request) Clone() *Request {
 	b := WithBody(r.Opts.RawBody)
 	b.apply(r.Opts)
 	req, _ := http.NewRequest(r.Opts.Method, r.Opts.Url, r.Opts.Body)
+	setStreamBody(req, r.Opts)
+	r.Opts.trackUpload(req)
 	for k, v := range r.Opts.Header {
 		req.Header.Set(k, v)
 	}
@@ -134,6 +187,37 @@ func (r *Request) Clone() *Request {
 	return newRequest
 }
 
+// Rewind is reset the http request body from RawBody , so the request can be send again (eg: retry)
+// io.Reader body only can be rewind when it is a io.Seeker
+func (r *Request) Rewind() error {
+	if reader, ok := r.Opts.RawBody.(io.Reader); ok {
+		seeker, ok := reader.(io.Seeker)
+		if !ok {
+			return fmt.Errorf("%w: %T is not a io.Seeker", config.ReplayBodyError, reader)
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("%w: %v", config.ReplayBodyError, err)
+		}
+	}
+	if m, ok := r.Opts.RawBody.(*Multipart); ok {
+		if err := m.rewind(); err != nil {
+			return err
+		}
+	}
+	b := WithBody(r.Opts.RawBody)
+	b.apply(r.Opts)
+	req := r.HttpReq.Clone(r.HttpReq.Context())
+	req.Body = nil
+	if rc, ok := r.Opts.Body.(io.ReadCloser); ok {
+		req.Body = rc
+	} else if r.Opts.Body != nil {
+		req.Body = io.NopCloser(r.Opts.Body)
+	}
+	r.Opts.trackUpload(req)
+	r.HttpReq = req
+	return nil
+}
+
 func NewReuqest(method string, url string, opts ...ReqOption) (*Request, error) {
 	options := &ReqOptions{
 		Method:      method,
@@ -143,6 +227,17 @@ func NewReuqest(method string, url string, opts ...ReqOption) (*Request, error)
 	for _, o := range opts {
 		o.apply(options)
 	}
+	if options.err != nil {
+		return nil, options.err
+	}
+	if options.PathParams != nil {
+		expanded, template, err := expandPath(options.Url, options.PathParams)
+		if err != nil {
+			return nil, err
+		}
+		options.Url = expanded
+		options.PathTemplate = template
+	}
 	if options.ContentType != "" {
 		if options.Header == nil {
 			options.Header = make(map[string]string)
@@ -150,12 +245,14 @@ func NewReuqest(method string, url string, opts ...ReqOption) (*Request, error)
 		options.Header["Content-Type"] = options.ContentType
 	}
 	r, err := http.NewRequest(options.Method, options.Url, options.Body)
-	if options.Context != nil {
-		r = r.WithContext(options.Context)
-	}
 	if err != nil {
 		return nil, err
 	}
+	if options.Context != nil {
+		r = r.WithContext(options.Context)
+	}
+	setStreamBody(r, options)
+	options.trackUpload(r)
 	for k, v := range options.Header {
 		r.Header.Set(k, v)
 	}
@@ -166,7 +263,23 @@ func NewReuqest(method string, url string, opts ...ReqOption) (*Request, error)
 	}, nil
 }
 
+// setStreamBody is set the ContentLength and GetBody of streaming body , which are unknown by http.NewRequest
+func setStreamBody(req *http.Request, opts *ReqOptions) {
+	if opts.ContentLength > 0 {
+		req.ContentLength = opts.ContentLength
+	}
+	if m, ok := opts.RawBody.(*Multipart); ok {
+		req.GetBody = func() (io.ReadCloser, error) {
+			if err := m.rewind(); err != nil {
+				return nil, err
+			}
+			return m.body(), nil
+		}
+	}
+}
+
 // 组建query请求参数,sortAsc true为小到大,false为大到小,nil不排序  a=123&b=321
+// the key and value are escaped by url.QueryEscape
 func HttpBuildQuery(args map[string]string, sortAsc ...bool) string {
 	str := ""
 	if len(args) == 0 {
@@ -183,11 +296,11 @@ func HttpBuildQuery(args map[string]string, sortAsc ...bool) string {
 			sort.Sort(sort.Reverse(sort.StringSlice(keys)))
 		}
 		for _, k := range keys {
-			str += "&" + k + "=" + args[k]
+			str += "&" + url.QueryEscape(k) + "=" + url.QueryEscape(args[k])
 		}
 	} else {
 		for k, v := range args {
-			str += "&" + k + "=" + v
+			str += "&" + url.QueryEscape(k) + "=" + url.QueryEscape(v)
 		}
 	}
 	return str[1:]
@@ -0,0 +1,328 @@
+package request
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signer is sign the request just before it is sent , eg: set signature header
+type Signer interface {
+	Sign(r *Request) error
+}
+
+// SignerFunc is a func as Signer
+type SignerFunc func(r *Request) error
+
+func (f SignerFunc) Sign(r *Request) error {
+	return f(r)
+}
+
+type SignerOption struct{ Signer }
+
+func (s SignerOption) apply(opts *ReqOptions) {
+	opts.Signer = s.Signer
+}
+
+// WithSigner is sign this request , it is prior to the signer of client
+func WithSigner(s Signer) ReqOption {
+	return SignerOption{s}
+}
+
+// bodyBytes is the whole request body , the body can be read again after this
+func (r *Request) bodyBytes() ([]byte, error) {
+	if r.HttpReq.Body == nil || r.HttpReq.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.HttpReq.GetBody != nil {
+		body, err := r.HttpReq.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(untracked(body))
+	}
+	// the progress of upload is not reported for reading the body here
+	body := r.HttpReq.Body
+	b, err := io.ReadAll(untracked(body))
+	if err != nil {
+		return nil, err
+	}
+	body.Close()
+	r.HttpReq.Body = io.NopCloser(bytes.NewReader(b))
+	r.HttpReq.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	if _, ok := body.(*progressBody); ok && r.Opts != nil {
+		r.Opts.trackUpload(r.HttpReq)
+	}
+	return b, nil
+}
+
+// BodySHA256 is hex encoded sha256 of request body
+func (r *Request) BodySHA256() (string, error) {
+	b, err := r.bodyBytes()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// URIEncode is rfc3986 percent encode , only A-Z a-z 0-9 - _ . ~ are not encoded ,
+// the slash is not encoded if keepSlash
+func URIEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (keepSlash && c == '/') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// CanonicalQuery is the query encoded by URIEncode and sorted by encoded key then encoded value
+func CanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	pairs := make([][2]string, 0, len(query))
+	for k, vs := range query {
+		for _, v := range vs {
+			pairs = append(pairs, [2]string{URIEncode(k, false), URIEncode(v, false)})
+		}
+	}
+	// sort the pairs instead of the joined strings , "a-b=1" is sorted before "a=2" if joined
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	joined := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		joined = append(joined, pair[0]+"="+pair[1])
+	}
+	return strings.Join(joined, "&")
+}
+
+// canonicalHeaders is lower case name:trimmed value lines and the signed header names
+func canonicalHeaders(r *Request, names []string) (string, string) {
+	lower := make([]string, 0, len(names))
+	for _, name := range names {
+		lower = append(lower, strings.ToLower(name))
+	}
+	sort.Strings(lower)
+	var b strings.Builder
+	for _, name := range lower {
+		var value string
+		if name == "host" {
+			value = r.HttpReq.Host
+			if value == "" {
+				value = r.HttpReq.URL.Host
+			}
+		} else {
+			value = strings.Join(r.HttpReq.Header.Values(name), ",")
+		}
+		b.WriteString(name + ":" + strings.Join(strings.Fields(value), " ") + "\n")
+	}
+	return b.String(), strings.Join(lower, ";")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// HMACSigner is sign the request by HMAC-SHA256 of canonical string ,
+// the default canonical string is lines of:
+// method , path , canonical query , canonical headers , signed headers , timestamp , nonce , body sha256
+type HMACSigner struct {
+	KeyID           string
+	Secret          []byte
+	SignatureHeader string   // default Authorization
+	TimestampHeader string   // default X-Timestamp
+	NonceHeader     string   // default X-Nonce
+	SignedHeaders   []string // headers in canonical string , host is always included
+	// CanonicalString is custom canonical string , the timestamp and nonce headers already set
+	CanonicalString func(r *Request, timestamp string, nonce string) (string, error)
+	Now             func() time.Time
+}
+
+func (s *HMACSigner) Sign(r *Request) error {
+	signatureHeader := s.SignatureHeader
+	if signatureHeader == "" {
+		signatureHeader = "Authorization"
+	}
+	timestampHeader := s.TimestampHeader
+	if timestampHeader == "" {
+		timestampHeader = "X-Timestamp"
+	}
+	nonceHeader := s.NonceHeader
+	if nonceHeader == "" {
+		nonceHeader = "X-Nonce"
+	}
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	r.HttpReq.Header.Set(timestampHeader, timestamp)
+	r.HttpReq.Header.Set(nonceHeader, nonce)
+
+	var (
+		canonical     string
+		signedHeaders string
+		err           error
+	)
+	if s.CanonicalString != nil {
+		canonical, err = s.CanonicalString(r, timestamp, nonce)
+	} else {
+		canonical, signedHeaders, err = s.canonicalString(r, timestamp, nonce)
+	}
+	if err != nil {
+		return err
+	}
+	signature := hex.EncodeToString(hmacSHA256(s.Secret, canonical))
+	value := fmt.Sprintf("HMAC-SHA256 KeyId=%s, Signature=%s", s.KeyID, signature)
+	if signedHeaders != "" {
+		value = fmt.Sprintf("HMAC-SHA256 KeyId=%s, SignedHeaders=%s, Signature=%s", s.KeyID, signedHeaders, signature)
+	}
+	r.HttpReq.Header.Set(signatureHeader, value)
+	return nil
+}
+
+func (s *HMACSigner) canonicalString(r *Request, timestamp string, nonce string) (string, string, error) {
+	bodyHash, err := r.BodySHA256()
+	if err != nil {
+		return "", "", err
+	}
+	headers, signedHeaders := canonicalHeaders(r, append([]string{"host"}, s.SignedHeaders...))
+	path := r.HttpReq.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{
+		r.HttpReq.Method,
+		path,
+		CanonicalQuery(r.HttpReq.URL),
+		headers,
+		signedHeaders,
+		timestamp,
+		nonce,
+		bodyHash,
+	}, "\n")
+	return canonical, signedHeaders, nil
+}
+
+const (
+	sigV4Algorithm   = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat  = "20060102T150405Z"
+	UnsignedPayload  = "UNSIGNED-PAYLOAD"
+	sigV4DateFormat  = "20060102"
+	sigV4Termination = "aws4_request"
+)
+
+// SigV4Signer is AWS Signature Version 4 , host , content-type and x-amz-* headers are signed
+type SigV4Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Service         string
+	UnsignedPayload bool // use UNSIGNED-PAYLOAD as body hash , only for s3
+	Now             func() time.Time
+}
+
+func (s *SigV4Signer) Sign(r *Request) error {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	t := now().UTC()
+	amzDate := t.Format(sigV4TimeFormat)
+	scope := strings.Join([]string{t.Format(sigV4DateFormat), s.Region, s.Service, sigV4Termination}, "/")
+
+	payloadHash := UnsignedPayload
+	if !s.UnsignedPayload {
+		hash, err := r.BodySHA256()
+		if err != nil {
+			return err
+		}
+		payloadHash = hash
+	}
+	r.HttpReq.Header.Set("X-Amz-Date", amzDate)
+	if s.Service == "s3" {
+		r.HttpReq.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if s.SessionToken != "" {
+		r.HttpReq.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	names := []string{"host"}
+	for name := range r.HttpReq.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			names = append(names, lower)
+		}
+	}
+	headers, signedHeaders := canonicalHeaders(r, names)
+
+	canonicalRequest := strings.Join([]string{
+		r.HttpReq.Method,
+		s.canonicalURI(r.HttpReq.URL),
+		CanonicalQuery(r.HttpReq.URL),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), t.Format(sigV4DateFormat))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, sigV4Termination)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	r.HttpReq.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.AccessKeyID, scope, signedHeaders, signature,
+	))
+	return nil
+}
+
+// canonicalURI is the encoded path , s3 encode once and the others encode twice
+func (s *SigV4Signer) canonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	encoded := URIEncode(path, true)
+	if s.Service != "s3" {
+		encoded = URIEncode(encoded, true)
+	}
+	return encoded
+}
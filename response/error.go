@@ -0,0 +1,72 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/zengzhengrong/request/config"
+)
+
+// StatusError is the error of non-2xx response , errors.Is(err, config.StatusCodeError) is true
+type StatusError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Header     http.Header
+	Body       []byte // truncated to config.DefaultErrorBodySize
+}
+
+// NewStatusError is build StatusError from response and the body already read
+func NewStatusError(resp *http.Response, body []byte) *StatusError {
+	e := &StatusError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.String()
+	}
+	if len(body) > config.DefaultErrorBodySize {
+		body = body[:config.DefaultErrorBodySize]
+	}
+	e.Body = body
+	return e
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s %s %d %s %s", config.StatusCodeError, e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+func (e *StatusError) Unwrap() error {
+	return config.StatusCodeError
+}
+
+// isSuccess is StatusCode 2xx
+func isSuccess(resp *http.Response) bool {
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// Raise is return the request error or *StatusError if response is not 2xx , otherwise nil
+func (r *Response) Raise() error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if r.Resp == nil || isSuccess(r.Resp) {
+		return nil
+	}
+	return NewStatusError(r.Resp, r.Body)
+}
+
+// DecodeError is the error of decoding response body into a value
+type DecodeError struct {
+	ContentType string
+	Err         error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode response body [content-type=%s]: %v", e.ContentType, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
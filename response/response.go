@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/tidwall/gjson"
+	"github.com/zengzhengrong/request/codec"
 )
 
 // Response is  interface TODO:
@@ -146,3 +147,24 @@ func (r *Response) GetKeyStruct(v any, key string) error {
 	}
 	return nil
 }
+
+// Decode is decode the body into v by the codec of response Content-Type , see codec.Lookup ,
+// json is used if the Content-Type is empty or has no codec
+func (r *Response) Decode(v any) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if r.Body == nil {
+		defer r.Resp.Body.Close()
+		body, err := io.ReadAll(r.Resp.Body)
+		if err != nil {
+			return err
+		}
+		r.Body = body
+	}
+	c, ok := codec.Lookup(r.Resp.Header.Get("Content-Type"))
+	if !ok {
+		c = codec.JSON
+	}
+	return c.Unmarshal(r.Body, v)
+}
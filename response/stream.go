@@ -0,0 +1,165 @@
+package response
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONStream is the iterator of the json values in body , see Stream
+type JSONStream[T any] struct {
+	resp    *Response
+	body    io.ReadCloser
+	dec     *json.Decoder
+	array   bool // the body is a top-level json array
+	started bool
+	done    bool
+	value   T
+	err     error
+}
+
+// Stream is decode the body one value by one value without reading the whole body ,
+// the body could be newline delimited json or a top-level json array (the body start with "[") ,
+// Resp.Body is closed when the iterator is done , call Close if you stop before done
+//
+//	s := response.Stream[Item](&resp)
+//	defer s.Close()
+//	for s.Next() {
+//		item := s.Value()
+//	}
+//	if err := s.Err(); err != nil {}
+func Stream[T any](r *Response) *JSONStream[T] {
+	return &JSONStream[T]{resp: r}
+}
+
+// Next is decode the next value , false if there is no more value or an error occurred
+func (s *JSONStream[T]) Next() bool {
+	if s.done {
+		return false
+	}
+	if !s.started {
+		s.started = true
+		if err := s.start(); err != nil {
+			return s.stop(err)
+		}
+	}
+	var v T
+	if s.array {
+		if !s.dec.More() {
+			return s.stop(s.end())
+		}
+		if err := s.dec.Decode(&v); err != nil {
+			return s.stop(err)
+		}
+	} else {
+		if err := s.dec.Decode(&v); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return s.stop(err)
+		}
+	}
+	s.value = v
+	return true
+}
+
+// Value is the value decoded by the last Next
+func (s *JSONStream[T]) Value() T {
+	return s.value
+}
+
+// Err is the error which stop the iterator , nil if all values are decoded
+func (s *JSONStream[T]) Err() error {
+	return s.err
+}
+
+// Close is stop the iterator and close the body
+func (s *JSONStream[T]) Close() error {
+	s.done = true
+	if s.body == nil {
+		return nil
+	}
+	body := s.body
+	s.body = nil
+	return body.Close()
+}
+
+// start is choose the body and check whether it is a json array
+func (s *JSONStream[T]) start() error {
+	r := s.resp
+	if r.Err != nil {
+		return r.Err
+	}
+	var reader io.Reader
+	if r.Body != nil {
+		reader = bytes.NewReader(r.Body)
+	} else {
+		s.body = r.Resp.Body
+		reader = r.Resp.Body
+	}
+	br := bufio.NewReader(reader)
+	first, err := peekNonSpace(br)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	s.dec = json.NewDecoder(br)
+	if first == '[' {
+		s.array = true
+		// consume "["
+		if _, err := s.dec.Token(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// end is consume the "]" of array , there should be nothing after it
+func (s *JSONStream[T]) end() error {
+	if _, err := s.dec.Token(); err != nil {
+		return err
+	}
+	if tok, err := s.dec.Token(); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("unexpected %v after json array", tok)
+	}
+	return nil
+}
+
+func (s *JSONStream[T]) stop(err error) bool {
+	s.err = err
+	s.Close()
+	return false
+}
+
+// peekNonSpace is skip the json whitespace and return the next byte without consuming it
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
+
+// EachJSON is call fn with the raw json of every value in body without reading the whole body ,
+// the body could be newline delimited json or a top-level json array , see Stream ,
+// it stop and return the error of fn , the body is closed when it return
+func (r *Response) EachJSON(fn func(raw []byte) error) error {
+	s := Stream[json.RawMessage](r)
+	defer s.Close()
+	for s.Next() {
+		if err := fn(s.Value()); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+)
+
+func TestBasicAndBearerAuth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+
+	resp := client.NewClient(client.WithBasicAuth("user", "pass")).GET(ts.URL)
+	assert.Equal(t, "Basic dXNlcjpwYXNz", string(resp.Body))
+
+	c := client.NewClient(client.WithBearerToken("token"))
+	resp = c.GET(ts.URL)
+	assert.Equal(t, "Bearer token", string(resp.Body))
+	// header of call site win
+	resp = c.GET(ts.URL, nil, map[string]string{"Authorization": "Bearer other"})
+	assert.Equal(t, "Bearer other", string(resp.Body))
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var issued int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "id" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "read write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(&issued, 1)
+		json.NewEncoder(w).Encode(client.Token{
+			AccessToken: fmt.Sprintf("token-%d", n),
+			TokenType:   "bearer",
+			ExpiresIn:   3600,
+		})
+	}))
+	defer tokenServer.Close()
+
+	// the api revoke token-1 after the first call
+	var calls int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		auth := r.Header.Get("Authorization")
+		if auth == "Bearer token-1" && n > 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(auth + " " + string(body)))
+	}))
+	defer api.Close()
+
+	c := client.NewClient(client.WithOAuth2ClientCredentials(tokenServer.URL, "id", "secret", "read", "write"))
+	resp := c.POST(api.URL, "first")
+	assert.Equal(t, "Bearer token-1 first", string(resp.Body))
+	resp = c.POST(api.URL, "second")
+	assert.Equal(t, "Bearer token-2 second", string(resp.Body))
+	resp = c.GET(api.URL)
+	assert.Equal(t, "Bearer token-2 ", string(resp.Body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&issued))
+}
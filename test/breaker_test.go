@@ -0,0 +1,99 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/opts/client"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		down int32 = 1
+		hits int32
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	var (
+		mu          sync.Mutex
+		transitions []string
+	)
+	c := client.NewClient(
+		client.WithRetry(client.RetryPolicy{MaxAttempts: 5, InitialInterval: time.Millisecond}),
+		client.WithCircuitBreaker(client.CircuitBreakerConfig{
+			MinRequests:  2,
+			OpenDuration: 100 * time.Millisecond,
+			OnStateChange: func(host string, from client.CircuitState, to client.CircuitState) {
+				mu.Lock()
+				defer mu.Unlock()
+				transitions = append(transitions, from.String()+">"+to.String())
+			},
+		}),
+	)
+
+	// 2 failed attempts open the breaker , the third attempt is not retried
+	resp := c.GET(ts.URL)
+	var open *client.ErrCircuitOpen
+	assert.True(t, errors.As(resp.Err, &open))
+	assert.True(t, errors.Is(resp.Err, config.CircuitOpenError))
+	assert.Equal(t, client.CircuitOpen, open.State)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	resp = c.GET(ts.URL)
+	assert.True(t, errors.Is(resp.Err, config.CircuitOpenError))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	// the probe of half-open succeed and close the breaker
+	atomic.StoreInt32(&down, 0)
+	time.Sleep(150 * time.Millisecond)
+	resp = c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"closed>open", "open>half-open", "half-open>closed"}, transitions)
+}
+
+func TestCircuitBreakerHalfOpenFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	var states []client.CircuitState
+	c := client.NewClient(client.WithCircuitBreaker(client.CircuitBreakerConfig{
+		MinRequests:  1,
+		OpenDuration: 50 * time.Millisecond,
+		OnStateChange: func(host string, from client.CircuitState, to client.CircuitState) {
+			states = append(states, to)
+		},
+	}))
+	resp := c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	resp = c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	resp = c.GET(ts.URL)
+	assert.True(t, errors.Is(resp.Err, config.CircuitOpenError))
+	assert.Equal(t, []client.CircuitState{client.CircuitOpen, client.CircuitHalfOpen, client.CircuitOpen}, states)
+}
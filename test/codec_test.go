@@ -0,0 +1,136 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/codec"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+)
+
+type codecItem struct {
+	Name    string    `json:"name" xml:"name" yaml:"name" form:"name" msgpack:"name"`
+	Count   int       `json:"count" xml:"count" yaml:"count" form:"count" msgpack:"count"`
+	Tags    []string  `json:"tags" xml:"tags" yaml:"tags" form:"tag" msgpack:"tags"`
+	Created time.Time `json:"created" xml:"created" yaml:"created" form:"created" msgpack:"created"`
+	Note    *string   `json:"note,omitempty" xml:"note,omitempty" yaml:"note,omitempty" form:"note,omitempty" msgpack:"note,omitempty"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	item := codecItem{Name: "a&b", Count: -300, Tags: []string{"x", "y"}, Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	for _, c := range []codec.Codec{codec.JSON, codec.XML, codec.YAML, codec.Form, codec.MsgPack} {
+		b, err := c.Marshal(item)
+		if err != nil {
+			panic(err)
+		}
+		var got codecItem
+		if err := c.Unmarshal(b, &got); err != nil {
+			panic(err)
+		}
+		got.Created = got.Created.UTC()
+		assert.Equal(t, item, got, c.ContentType())
+	}
+}
+
+func TestMsgPack(t *testing.T) {
+	b, err := codec.MsgPack.Marshal(map[string]any{"compact": true, "schema": 0})
+	if err != nil {
+		panic(err)
+	}
+	// the example of msgpack.org
+	assert.Equal(t, "82a7636f6d70616374c3a6736368656d6100", hex.EncodeToString(b))
+
+	b, err = codec.MsgPack.Marshal([]any{nil, 1.5, int64(-1), uint64(1 << 40), "é", []byte{1, 2}, map[int]string{1: "x"}})
+	if err != nil {
+		panic(err)
+	}
+	var v any
+	if err := codec.MsgPack.Unmarshal(b, &v); err != nil {
+		panic(err)
+	}
+	assert.Equal(t, []any{nil, 1.5, int64(-1), int64(1 << 40), "é", []byte{1, 2}, map[any]any{int64(1): "x"}}, v)
+
+	var small struct {
+		N int8 `msgpack:"n"`
+	}
+	b, _ = codec.MsgPack.Marshal(map[string]int{"n": 300})
+	assert.NotNil(t, codec.MsgPack.Unmarshal(b, &small))
+	assert.NotNil(t, codec.MsgPack.Unmarshal([]byte{0x92, 0x01}, &v))
+
+	// deep nesting is an error instead of stack overflow
+	deep := bytes.Repeat([]byte{0x91}, 4<<20)
+	deep = append(deep, 0x01)
+	var anything any
+	err = codec.MsgPack.Unmarshal(deep, &anything)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "max depth")
+	nested := append(bytes.Repeat([]byte{0x91}, 100), 0x01)
+	assert.Nil(t, codec.MsgPack.Unmarshal(nested, &anything))
+}
+
+func TestCodecLookup(t *testing.T) {
+	c, ok := codec.Lookup("application/problem+json; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, codec.JSON, c)
+	c, ok = codec.Lookup("Text/XML")
+	assert.True(t, ok)
+	assert.Equal(t, codec.XML, c)
+	_, ok = codec.Lookup("text/plain")
+	assert.False(t, ok)
+
+	codec.Register(codec.YAML, "application/x-codec-test")
+	defer codec.Unregister("application/x-codec-test")
+	c, ok = codec.Lookup("application/x-codec-test")
+	assert.True(t, ok)
+	assert.Equal(t, codec.YAML, c)
+}
+
+func TestCodecUnregister(t *testing.T) {
+	codec.Register(codec.JSON, "application/x-codec-unregister")
+	codec.Unregister("application/x-codec-unregister; charset=utf-8")
+	_, ok := codec.Lookup("application/x-codec-unregister")
+	assert.False(t, ok)
+}
+
+func TestFormUnmarshal(t *testing.T) {
+	var m map[string]string
+	if err := codec.Form.Unmarshal([]byte("a=1&b=x+y"), &m); err != nil {
+		panic(err)
+	}
+	assert.Equal(t, map[string]string{"a": "1", "b": "x y"}, m)
+	var values url.Values
+	if err := codec.Form.Unmarshal([]byte("a=1&a=2"), &values); err != nil {
+		panic(err)
+	}
+	assert.Equal(t, []string{"1", "2"}, values["a"])
+}
+
+func TestEncodedAndDecode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	item := codecItem{Name: "n", Count: 1, Tags: []string{"t"}, Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	c := client.NewClient()
+	for _, cd := range []codec.Codec{codec.XML, codec.YAML, codec.MsgPack, codec.Form} {
+		got, resp, err := client.Post[codecItem](context.Background(), c, ts.URL, nil, request.WithEncoded(cd, item))
+		if err != nil {
+			panic(err)
+		}
+		assert.Equal(t, cd.ContentType(), resp.Resp.Header.Get("Content-Type"))
+		got.Created = got.Created.UTC()
+		assert.Equal(t, item, got, cd.ContentType())
+	}
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/curl"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/opts/pipline"
+	"github.com/zengzhengrong/request/response"
+)
+
+func TestCtxDeadline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c := client.NewClient()
+	resp := c.GETCtx(ctx, ts.URL)
+	assert.True(t, errors.Is(resp.Err, context.DeadlineExceeded))
+
+	resp = curl.POSTCtx(ctx, ts.URL, jsonbody)
+	assert.True(t, errors.Is(resp.Err, context.DeadlineExceeded))
+}
+
+func TestPipLineCtx(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer ts.Close()
+
+	p := pipline.NewPipLine(
+		pipline.WithParall(true),
+		pipline.WithDefaultClient(),
+		pipline.WithIn(func(ctx context.Context, cli *client.Client) ([]byte, error) {
+			resp := cli.GETCtx(ctx, ts.URL+"/a")
+			return resp.Body, resp.Err
+		}, func(ctx context.Context, cli *client.Client) ([]byte, error) {
+			resp := cli.GETCtx(ctx, ts.URL+"/b")
+			return resp.Body, resp.Err
+		}),
+		pipline.WithOut(func(ctx context.Context, cli *client.Client, Ins ...[]byte) response.Response {
+			return cli.POSTCtx(ctx, ts.URL+string(Ins[0])+string(Ins[1]), nil)
+		}),
+	)
+	resp := p.Result(context.Background())
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "/a/b", string(resp.Body))
+}
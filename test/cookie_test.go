@@ -0,0 +1,73 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+)
+
+func cookieServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", MaxAge: 3600})
+		case "/admin":
+			c, err := r.Cookie("session")
+			if err != nil || c.Value != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("welcome"))
+		}
+	}))
+}
+
+func TestCookieJarSession(t *testing.T) {
+	ts := cookieServer()
+	defer ts.Close()
+
+	c := client.NewClient(client.WithCookieJar())
+	resp := c.POST(ts.URL+"/login", formbody)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	resp = c.GET(ts.URL + "/admin")
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+	assert.Equal(t, "welcome", string(resp.Body))
+
+	cookies := c.Cookies(ts.URL + "/admin")
+	assert.Equal(t, 1, len(cookies))
+	assert.Equal(t, "session", cookies[0].Name)
+
+	// without jar the session is lost
+	resp = client.NewClient().GET(ts.URL + "/admin")
+	assert.Equal(t, http.StatusUnauthorized, resp.Resp.StatusCode)
+}
+
+func TestCookieJarPersist(t *testing.T) {
+	ts := cookieServer()
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "cookies.json")
+
+	jar, err := client.LoadCookieJar(path) // file not exist yet
+	if err != nil {
+		panic(err)
+	}
+	c := client.NewClient(client.WithCookieJar(jar))
+	c.POST(ts.URL+"/login", formbody)
+	if err := jar.Save(path); err != nil {
+		panic(err)
+	}
+
+	loaded, err := client.LoadCookieJar(path)
+	if err != nil {
+		panic(err)
+	}
+	c = client.NewClient(client.WithCookieJar(loaded))
+	resp := c.GET(ts.URL + "/admin")
+	assert.Equal(t, "welcome", string(resp.Body))
+}
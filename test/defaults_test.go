@@ -0,0 +1,86 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+)
+
+func TestClientDefaults(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(
+		client.WithBaseURL(ts.URL+"/v2"),
+		client.WithDefaultHeaders(map[string]string{"X-Tenant": "a", "X-Trace": "default"}),
+		client.WithDefaultQuery(map[string]string{"lang": "en", "page": "1"}),
+		client.WithUserAgent("request-test/1.0"),
+	)
+	resp := c.GET("users/1", map[string]string{"page": "2"}, map[string]string{"X-Trace": "call"})
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "/v2/users/1", got.URL.Path)
+	assert.Equal(t, "2", got.URL.Query().Get("page"))
+	assert.Equal(t, "en", got.URL.Query().Get("lang"))
+	assert.Equal(t, "a", got.Header.Get("X-Tenant"))
+	assert.Equal(t, "call", got.Header.Get("X-Trace"))
+	assert.Equal(t, "request-test/1.0", got.UserAgent())
+
+	// root relative path and absolute url
+	resp = c.GET("/health")
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "/health", got.URL.Path)
+	resp = c.GET(ts.URL + "/other")
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "/other", got.URL.Path)
+	assert.Equal(t, "lang=en&page=1", got.URL.RawQuery)
+}
+
+func TestClientDefaultsDo(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(
+		client.WithBaseURL(ts.URL+"/v2"),
+		client.WithDefaultHeaders(map[string]string{"X-Tenant": "a"}),
+		client.WithDefaultQuery(map[string]string{"lang": "en", "page": "1"}),
+		client.WithUserAgent("request-test/1.0"),
+	)
+	// the request is not built by the client
+	r, err := request.NewReuqest(http.MethodGet, "users/1", request.WithQuery(map[string]string{"page": "2"}))
+	if err != nil {
+		panic(err)
+	}
+	resp, err := c.Do(r)
+	if err != nil {
+		panic(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, "/v2/users/1", got.URL.Path)
+	assert.Equal(t, "page=2&lang=en", got.URL.RawQuery)
+	assert.Equal(t, "a", got.Header.Get("X-Tenant"))
+	assert.Equal(t, "request-test/1.0", got.UserAgent())
+	assert.Equal(t, "page=2&lang=en", r.Opts.Query)
+	assert.Equal(t, ts.URL+"/v2/users/1?page=2&lang=en", r.Opts.Url)
+}
+
+func TestInvalidBaseURL(t *testing.T) {
+	c := client.NewClient(client.WithBaseURL("api/v2"))
+	resp := c.GET("users")
+	assert.NotNil(t, resp.Err)
+}
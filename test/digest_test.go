@@ -0,0 +1,83 @@
+package test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+)
+
+// digestServer is a minimal rfc7616 server , it only accept the algorithm of the first challenge
+func digestServer(user, pass string) (*httptest.Server, *[]string) {
+	const realm, nonce = "camera", "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+	var (
+		mu  sync.Mutex
+		ncs []string
+	)
+	paramRe := regexp.MustCompile(`(\w+)=(?:"([^"]*)"|([^,\s]*))`)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Digest ") {
+			w.Header().Add("WWW-Authenticate", `Digest realm="`+realm+`", qop="auth", algorithm=SHA-256, nonce="`+nonce+`", opaque="op"`)
+			w.Header().Add("WWW-Authenticate", `Digest realm="`+realm+`", qop="auth", algorithm=MD5, nonce="`+nonce+`", opaque="op"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		params := map[string]string{}
+		for _, m := range paramRe.FindAllStringSubmatch(auth, -1) {
+			params[m[1]] = m[2] + m[3]
+		}
+		var h func() hash.Hash
+		switch params["algorithm"] {
+		case "SHA-256":
+			h = sha256.New
+		case "MD5":
+			h = md5.New
+		}
+		hexHash := func(s string) string {
+			hh := h()
+			hh.Write([]byte(s))
+			return hex.EncodeToString(hh.Sum(nil))
+		}
+		ha1 := hexHash(user + ":" + realm + ":" + pass)
+		ha2 := hexHash(r.Method + ":" + r.URL.RequestURI())
+		expect := hexHash(strings.Join([]string{ha1, nonce, params["nc"], params["cnonce"], "auth", ha2}, ":"))
+		if params["algorithm"] != "SHA-256" || params["response"] != expect || params["opaque"] != "op" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		ncs = append(ncs, params["nc"])
+		mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})), &ncs
+}
+
+func TestDigestAuth(t *testing.T) {
+	ts, ncs := digestServer("admin", "secret")
+	defer ts.Close()
+
+	c := client.NewClient(client.WithDigestAuth("admin", "secret"))
+	resp := c.POST(ts.URL+"/cgi-bin/config?action=set", jsonbody)
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+	assert.Equal(t, string(jsonbody), string(resp.Body))
+
+	// reuse the challenge without 401
+	resp = c.GET(ts.URL+"/cgi-bin/status", query)
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+	assert.Equal(t, []string{"00000001", "00000002"}, *ncs)
+
+	resp = client.NewClient(client.WithDigestAuth("admin", "wrong")).GET(ts.URL)
+	assert.Equal(t, http.StatusUnauthorized, resp.Resp.StatusCode)
+}
@@ -0,0 +1,344 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+)
+
+func downloadContent() []byte {
+	return bytes.Repeat([]byte("0123456789abcdef"), 64*1024) // 1MB
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownload(t *testing.T) {
+	content := downloadContent()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "a.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "a.bin")
+	var last request.Progress
+	err := client.NewClient().Download(
+		context.Background(),
+		ts.URL,
+		dest,
+		client.WithChecksum(sha256.New, checksum(content)),
+		client.WithProgress(func(p request.Progress) { last = p }),
+	)
+	if err != nil {
+		panic(err)
+	}
+	got, _ := os.ReadFile(dest)
+	assert.Equal(t, content, got)
+	assert.True(t, last.Done)
+	assert.Equal(t, int64(len(content)), last.Transferred)
+	assert.Equal(t, int64(len(content)), last.Total)
+	_, err = os.Stat(dest + ".part")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	_, err = os.Stat(dest + ".part.json")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := downloadContent()
+	half := len(content) / 2
+	var (
+		requests int32
+		ranges   []string
+		mu       sync.Mutex
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range")+"|"+r.Header.Get("If-Range"))
+		mu.Unlock()
+		w.Header().Set("ETag", `"v1"`)
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// send half of the file then hang until the client give up
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:half])
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		http.ServeContent(w, r, "a.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "a.bin")
+	c := client.NewClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := c.Download(ctx, ts.URL, dest, client.WithProgress(func(p request.Progress) {
+		if p.Transferred >= int64(half) {
+			cancel()
+		}
+	}, time.Nanosecond))
+	assert.NotNil(t, err)
+	part, _ := os.ReadFile(dest + ".part")
+	assert.Equal(t, content[:len(part)], part)
+
+	var first request.Progress
+	err = c.Download(context.Background(), ts.URL, dest, client.WithProgress(func(p request.Progress) {
+		if first.Total == 0 {
+			first = p
+		}
+	}, time.Nanosecond))
+	if err != nil {
+		panic(err)
+	}
+	got, _ := os.ReadFile(dest)
+	assert.Equal(t, content, got)
+	assert.Equal(t, []string{"|", "bytes=" + strconv.Itoa(len(part)) + `-|"v1"`}, ranges)
+	assert.GreaterOrEqual(t, first.Transferred, int64(len(part)))
+}
+
+func TestDownloadChanged(t *testing.T) {
+	var mu sync.Mutex
+	content := downloadContent()
+	etag := `"v1"`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tag, body := etag, content
+		mu.Unlock()
+		w.Header().Set("ETag", tag)
+		http.ServeContent(w, r, "a.bin", time.Time{}, bytes.NewReader(body))
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "a.bin")
+	c := client.NewClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Download(ctx, ts.URL, dest, client.WithProgress(func(p request.Progress) {
+		if p.Transferred > 0 {
+			cancel()
+		}
+	}, time.Nanosecond))
+
+	// the file is changed , If-Range does not match and the whole file is sent again
+	mu.Lock()
+	etag = `"v2"`
+	content = bytes.Repeat([]byte("x"), 1000)
+	mu.Unlock()
+	if err := c.Download(context.Background(), ts.URL, dest); err != nil {
+		panic(err)
+	}
+	got, _ := os.ReadFile(dest)
+	assert.Equal(t, content, got)
+}
+
+func TestDownloadSegments(t *testing.T) {
+	content := downloadContent()
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "a.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "a.bin")
+	err := client.NewClient().Download(
+		context.Background(),
+		ts.URL,
+		dest,
+		client.WithSegments(4),
+		client.WithChecksum(sha256.New, checksum(content)),
+	)
+	if err != nil {
+		panic(err)
+	}
+	got, _ := os.ReadFile(dest)
+	assert.Equal(t, content, got)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&requests))
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("content"))
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "a.bin")
+	err := client.NewClient().Download(context.Background(), ts.URL, dest, client.WithChecksum(sha256.New, checksum([]byte("other"))))
+	assert.True(t, errors.Is(err, config.ChecksumError))
+	_, err = os.Stat(dest)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	_, err = os.Stat(dest + ".part")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestDownloadSegmentsWithoutValidator(t *testing.T) {
+	content := downloadContent()
+	var (
+		mu     sync.Mutex
+		ranges []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		// no ETag and Last-Modified
+		http.ServeContent(w, r, "a.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "a.bin")
+	// the segments of last download can not be resumed without validator
+	os.WriteFile(dest+".part", bytes.Repeat([]byte("x"), len(content)), 0644)
+	os.WriteFile(dest+".part.json", []byte(`{"url":"`+ts.URL+`","size":1048576,"segments":[{"start":0,"end":1048575,"done":1000}]}`), 0644)
+
+	err := client.NewClient().Download(context.Background(), ts.URL, dest, client.WithSegments(4))
+	if err != nil {
+		panic(err)
+	}
+	got, _ := os.ReadFile(dest)
+	assert.Equal(t, content, got)
+	// probe then download by one stream
+	assert.Equal(t, []string{"bytes=0-0", ""}, ranges)
+}
+
+func TestDownloadIgnoreClientTimeout(t *testing.T) {
+	content := downloadContent()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		chunk := len(content) / 4
+		for i := 0; i < 4; i++ {
+			w.Write(content[i*chunk : (i+1)*chunk])
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "a.bin")
+	c := client.NewClient(client.WithTimeOut(100 * time.Millisecond))
+	if err := c.Download(context.Background(), ts.URL, dest); err != nil {
+		panic(err)
+	}
+	got, _ := os.ReadFile(dest)
+	assert.Equal(t, content, got)
+	assert.Equal(t, 100*time.Millisecond, c.HttpClient.Timeout)
+}
+
+// blockingReader is block after limit bytes are read until the request is canceled
+type blockingReader struct {
+	*bytes.Reader
+	ctx   context.Context
+	limit int
+	read  int
+}
+
+func (b *blockingReader) Read(p []byte) (int, error) {
+	if b.read >= b.limit {
+		<-b.ctx.Done()
+		return 0, b.ctx.Err()
+	}
+	if len(p) > b.limit-b.read {
+		p = p[:b.limit-b.read]
+	}
+	n, err := b.Reader.Read(p)
+	b.read += n
+	return n, err
+}
+
+func TestDownloadSegmentsResume(t *testing.T) {
+	content := downloadContent()
+	var (
+		block  int32 = 1
+		mu     sync.Mutex
+		ranges []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		w.Header().Set("ETag", `"v1"`)
+		var body io.ReadSeeker = bytes.NewReader(content)
+		if atomic.LoadInt32(&block) == 1 && r.Header.Get("Range") != "bytes=0-0" {
+			body = &blockingReader{Reader: bytes.NewReader(content), ctx: r.Context(), limit: 64 * 1024}
+		}
+		http.ServeContent(w, r, "a.bin", time.Time{}, body)
+	}))
+	defer ts.Close()
+	dest := filepath.Join(t.TempDir(), "a.bin")
+	c := client.NewClient()
+
+	// interrupt after the progress of segments is saved
+	interrupt := func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- c.Download(ctx, ts.URL, dest, client.WithSegments(4)) }()
+		var saved int64
+		for i := 0; i < 100 && saved == 0; i++ {
+			time.Sleep(50 * time.Millisecond)
+			var meta struct {
+				Segments []struct{ Done int64 }
+			}
+			b, _ := os.ReadFile(dest + ".part.json")
+			json.Unmarshal(b, &meta)
+			for _, seg := range meta.Segments {
+				saved += seg.Done
+			}
+		}
+		// saved while downloading , not only when it stop
+		assert.Greater(t, saved, int64(0))
+		cancel()
+		assert.NotNil(t, <-done)
+	}
+
+	interrupt()
+	atomic.StoreInt32(&block, 0)
+	mu.Lock()
+	ranges = nil
+	mu.Unlock()
+	if err := c.Download(context.Background(), ts.URL, dest, client.WithSegments(4)); err != nil {
+		panic(err)
+	}
+	got, _ := os.ReadFile(dest)
+	assert.Equal(t, content, got)
+	// every segment is resumed from the saved progress
+	assert.Equal(t, 4, len(ranges))
+	chunk := len(content) / 4
+	for _, r := range ranges {
+		var start, end int
+		fmt.Sscanf(r, "bytes=%d-%d", &start, &end)
+		assert.NotEqual(t, 0, start%chunk, r)
+	}
+
+	// the part file is removed , download again instead of failing
+	atomic.StoreInt32(&block, 1)
+	interrupt()
+	os.Remove(dest + ".part")
+	atomic.StoreInt32(&block, 0)
+	if err := c.Download(context.Background(), ts.URL, dest, client.WithSegments(4)); err != nil {
+		panic(err)
+	}
+	got, _ = os.ReadFile(dest)
+	assert.Equal(t, content, got)
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+	"github.com/zengzhengrong/request/response"
+)
+
+func TestGenericGetPost(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/get":
+			w.Write([]byte(`{"args":{"a":"` + r.URL.Query().Get("a") + `"}}`))
+		case "/post":
+			body, _ := io.ReadAll(r.Body)
+			w.Write([]byte(`{"form":` + string(body) + `}`))
+		case "/broken":
+			w.Write([]byte(`{"args":`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	c := client.NewClient()
+
+	result, resp, err := client.Get[Result](ctx, c, ts.URL+"/get", request.WithQuery(query))
+	if err != nil {
+		panic(err)
+	}
+	assert.Equal(t, "1", result.Args.A)
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+
+	posted, _, err := client.Post[*Result](ctx, c, ts.URL+"/post", jsonbody)
+	if err != nil {
+		panic(err)
+	}
+	assert.Equal(t, "2", posted.Form.BA)
+
+	_, _, err = client.Get[Result](ctx, c, ts.URL+"/missing")
+	assert.True(t, errors.Is(err, config.StatusCodeError))
+
+	_, _, err = client.Get[Result](ctx, c, ts.URL+"/broken")
+	var decodeErr *response.DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.False(t, errors.Is(err, config.StatusCodeError))
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+)
+
+func TestHedging(t *testing.T) {
+	var (
+		hits     int32
+		canceled = make(chan struct{}, 1)
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			// the first one is slow , it is canceled after the hedge win
+			select {
+			case <-r.Context().Done():
+				canceled <- struct{}{}
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Write([]byte("fast"))
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(client.WithHedging(20*time.Millisecond, 2))
+	start := time.Now()
+	resp := c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "fast", string(resp.Body))
+	assert.Less(t, time.Since(start), time.Second)
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("the slow request is not canceled")
+	}
+	assert.Equal(t, client.HedgingStats{Requests: 1, Hedges: 1, HedgeWins: 1}, c.HedgingStats())
+
+	// POST is not hedged
+	resp = c.POST(ts.URL, jsonbody)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, int64(1), c.HedgingStats().Requests)
+}
+
+func TestHedgingFastPrimary(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(client.WithHedging(time.Second, 1))
+	resp := c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "ok", string(resp.Body))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	assert.Equal(t, client.HedgingStats{Requests: 1}, c.HedgingStats())
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+)
+
+type jsonUser struct {
+	Name string `json:"name"`
+	Bio  string `json:"bio"`
+	Age  int    `json:"age"`
+}
+
+func TestWithJSON(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(client.WithRetry(client.RetryPolicy{InitialInterval: time.Millisecond}))
+	user := jsonUser{Name: "a", Bio: "<b>&</b>", Age: 3}
+	resp := c.SendRaw(context.Background(), http.MethodPost, ts.URL, request.WithContentType("text/plain"), request.WithJSON(user))
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	defer resp.Resp.Body.Close()
+	body, _ := io.ReadAll(resp.Resp.Body)
+	assert.Equal(t, `{"name":"a","bio":"<b>&</b>","age":3}`, string(body))
+	assert.Equal(t, config.JsonContectType, resp.Resp.Header.Get("Content-Type"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	r, err := request.NewReuqest(http.MethodPost, ts.URL, request.WithJSON(user))
+	if err != nil {
+		panic(err)
+	}
+	clone := r.Clone()
+	b, _ := io.ReadAll(clone.HttpReq.Body)
+	assert.Equal(t, `{"name":"a","bio":"<b>&</b>","age":3}`, string(b))
+}
+
+func TestWithJSONError(t *testing.T) {
+	_, err := request.NewReuqest(http.MethodPost, "https://api.x", request.WithJSON(make(chan int)))
+	assert.NotNil(t, err)
+}
+
+func TestWithBodyError(t *testing.T) {
+	r, err := request.NewReuqest(http.MethodPost, "https://api.x", request.WithBody(map[string]any{"a": 1, "b": "x"}))
+	if err != nil {
+		panic(err)
+	}
+	b, _ := io.ReadAll(r.HttpReq.Body)
+	assert.Equal(t, "a=1&b=x", string(b))
+
+	_, err = request.NewReuqest(http.MethodPost, "https://api.x", request.WithBody(map[string]any{"a": []int{1}}))
+	assert.True(t, errors.Is(err, config.BodyTypeError))
+
+	_, err = request.NewReuqest(http.MethodPost, "https://api.x", request.WithBody(jsonUser{}))
+	assert.True(t, errors.Is(err, config.BodyTypeError))
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+
+	var trace []string
+	named := func(name string) client.Middleware {
+		return func(next client.Handler) client.Handler {
+			return func(r *request.Request) (*http.Response, error) {
+				trace = append(trace, name+">")
+				resp, err := next(r)
+				trace = append(trace, "<"+name)
+				return resp, err
+			}
+		}
+	}
+	auth := func(next client.Handler) client.Handler {
+		return func(r *request.Request) (*http.Response, error) {
+			r.HttpReq.Header.Set("Authorization", "Bearer token")
+			return next(r)
+		}
+	}
+	c := client.NewClient(
+		client.WithMiddleware(named("first"), named("second")),
+		client.WithMiddleware(auth),
+	)
+
+	resp := c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "Bearer token", string(resp.Body))
+	assert.Equal(t, []string{"first>", "second>", "<second", "<first"}, trace)
+
+	trace = nil
+	raw := c.ReqRaw(http.MethodPost, ts.URL, jsonbody)
+	if raw.Err != nil {
+		panic(raw.Err)
+	}
+	raw.Resp.Body.Close()
+	assert.Equal(t, 4, len(trace))
+}
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/curl"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+)
+
+type multipartResult struct {
+	ContentLength int64
+	Fields        map[string]string
+	Files         map[string]string
+	ContentTypes  map[string]string
+}
+
+func multipartServer(t *testing.T, results chan<- multipartResult) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := multipartResult{
+			ContentLength: r.ContentLength,
+			Fields:        map[string]string{},
+			Files:         map[string]string{},
+			ContentTypes:  map[string]string{},
+		}
+		reader, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			b, _ := io.ReadAll(part)
+			if part.FileName() != "" {
+				res.Files[part.FormName()] = part.FileName() + ":" + string(b)
+				res.ContentTypes[part.FormName()] = part.Header.Get("Content-Type")
+			} else {
+				res.Fields[part.FormName()] = string(b)
+			}
+		}
+		results <- res
+	}))
+}
+
+func TestMultipart(t *testing.T) {
+	results := make(chan multipartResult, 1)
+	ts := multipartServer(t, results)
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("file content"), 0600); err != nil {
+		panic(err)
+	}
+	m := request.NewMultipart().
+		Field("name", `x "y"`).
+		File("doc", path).
+		Reader("raw", "b.bin", strings.NewReader("raw content"), -1, request.WithPartContentType("application/x-raw"))
+	c := client.NewClient()
+	resp := c.Send(context.Background(), http.MethodPost, ts.URL, request.WithMultipart(m))
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	res := <-results
+	assert.Equal(t, m.Len(), res.ContentLength)
+	assert.Equal(t, map[string]string{"name": `x "y"`}, res.Fields)
+	assert.Equal(t, map[string]string{"doc": "a.txt:file content", "raw": "b.bin:raw content"}, res.Files)
+	assert.Equal(t, "text/plain; charset=utf-8", res.ContentTypes["doc"])
+	assert.Equal(t, "application/x-raw", res.ContentTypes["raw"])
+}
+
+func TestMultipartChunkedAndRetry(t *testing.T) {
+	var attempts int32
+	results := make(chan multipartResult, 2)
+	ts := multipartServer(t, results)
+	defer ts.Close()
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		ts.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+
+	// unknown size is chunked , a non seekable reader can not be replayed
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("streamed"))
+		pw.Close()
+	}()
+	c := client.NewClient(client.WithRetry(client.RetryPolicy{InitialInterval: time.Millisecond}))
+	resp := c.Send(context.Background(), http.MethodPost, flaky.URL, request.WithMultipart(request.NewMultipart().Reader("f", "s.txt", pr, -1)))
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+	// the seekable reader is replayed by retry
+	atomic.StoreInt32(&attempts, 0)
+	m := request.NewMultipart().Reader("f", "s.txt", strings.NewReader("replayed"), -1)
+	resp = c.Send(context.Background(), http.MethodPost, flaky.URL, request.WithMultipart(m))
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+	res := <-results
+	assert.Equal(t, "s.txt:replayed", res.Files["f"])
+	assert.Equal(t, m.Len(), res.ContentLength)
+}
+
+func TestMultipartChunked(t *testing.T) {
+	results := make(chan multipartResult, 1)
+	ts := multipartServer(t, results)
+	defer ts.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("streamed"))
+		pw.Close()
+	}()
+	m := request.NewMultipart().Reader("f", "s.txt", pr, -1)
+	assert.Equal(t, int64(-1), m.Len())
+	resp := client.NewClient().Send(context.Background(), http.MethodPost, ts.URL, request.WithMultipart(m))
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	res := <-results
+	assert.Equal(t, int64(-1), res.ContentLength)
+	assert.Equal(t, "s.txt:streamed", res.Files["f"])
+}
+
+func TestCurlMultiPartUpload(t *testing.T) {
+	results := make(chan multipartResult, 1)
+	ts := multipartServer(t, results)
+	defer ts.Close()
+
+	resp := curl.POSTMultiPartUpload(
+		ts.URL,
+		map[string]io.Reader{"file": strings.NewReader("content")},
+		map[string]string{"file": "c.txt", "desc": "d"},
+		time.Second,
+	)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+	res := <-results
+	assert.Equal(t, map[string]string{"file": "c.txt:content"}, res.Files)
+	assert.Equal(t, map[string]string{"desc": "d"}, res.Fields)
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+)
+
+func TestPathParams(t *testing.T) {
+	r, err := request.NewReuqest(
+		http.MethodGet,
+		"https://api.x/users/{id}/orders/{orderID}",
+		request.WithPathParams(map[string]string{"id": "a/b c", "orderID": "7"}),
+		request.WithQuery(map[string]string{"q": "{x}"}),
+	)
+	if err != nil {
+		panic(err)
+	}
+	assert.Equal(t, "/users/a%2Fb%20c/orders/7", r.HttpReq.URL.EscapedPath())
+	assert.Equal(t, "{x}", r.HttpReq.URL.Query().Get("q"))
+	assert.Equal(t, "https://api.x/users/{id}/orders/{orderID}", r.Opts.PathTemplate)
+
+	type orderPath struct {
+		UserID  int    `path:"id"`
+		OrderID string `path:"orderID"`
+		Ignored string
+	}
+	r, err = request.NewReuqest(
+		http.MethodGet,
+		"https://api.x/users/{id}/orders/{orderID}",
+		request.WithPathParamsStruct(&orderPath{UserID: 1, OrderID: "x"}),
+	)
+	if err != nil {
+		panic(err)
+	}
+	assert.Equal(t, "https://api.x/users/1/orders/x", r.HttpReq.URL.String())
+}
+
+func TestPathParamsError(t *testing.T) {
+	_, err := request.NewReuqest(
+		http.MethodGet,
+		"https://api.x/users/{id}/orders/{orderID}",
+		request.WithPathParams(map[string]string{"id": "1"}),
+	)
+	assert.True(t, errors.Is(err, config.PathParamError))
+	assert.Contains(t, err.Error(), "missing params orderID")
+
+	_, err = request.NewReuqest(
+		http.MethodGet,
+		"https://api.x/users/{id}",
+		request.WithPathParams(map[string]string{"id": "1", "extra": "2"}),
+	)
+	assert.True(t, errors.Is(err, config.PathParamError))
+	assert.Contains(t, err.Error(), "unused params extra")
+
+	_, err = request.NewReuqest(http.MethodGet, "https://api.x/users/{id}", request.WithPathParamsStruct(1))
+	assert.True(t, errors.Is(err, config.PathParamError))
+}
+
+func TestPathParamsBaseURL(t *testing.T) {
+	var path string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(client.WithBaseURL(ts.URL + "/v2"))
+	resp := c.Send(context.Background(), http.MethodGet, "users/{id}", request.WithPathParams(map[string]string{"id": "é"}))
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "/v2/users/%C3%A9", path)
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+)
+
+func TestPoolOptionsAndStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	host := ts.Listener.Addr().String()
+
+	c := client.NewClient(
+		client.WithMaxIdleConns(50),
+		client.WithMaxIdleConnsPerHost(10),
+		client.WithMaxConnsPerHost(20),
+		client.WithIdleConnTimeout(time.Minute),
+		client.WithDialTimeout(time.Second),
+		client.WithKeepAlive(15*time.Second),
+		client.WithTLSHandshakeTimeout(2*time.Second),
+		client.WithResponseHeaderTimeout(3*time.Second),
+		client.WithForceAttemptHTTP2(false),
+	)
+	transport := c.HttpClient.Transport.(*http.Transport)
+	assert.Equal(t, 50, transport.MaxIdleConns)
+	assert.Equal(t, 10, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 20, transport.MaxConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.Equal(t, 2*time.Second, transport.TLSHandshakeTimeout)
+	assert.Equal(t, 3*time.Second, transport.ResponseHeaderTimeout)
+	assert.False(t, transport.ForceAttemptHTTP2)
+	assert.NotSame(t, http.DefaultTransport, c.HttpClient.Transport)
+
+	raw1 := c.ReqRaw(http.MethodGet, ts.URL, nil)
+	raw2 := c.ReqRaw(http.MethodGet, ts.URL, nil)
+	assert.Equal(t, client.HostStats{Open: 2, Active: 2, Idle: 0}, c.Stats()[host])
+
+	io.ReadAll(raw1.Resp.Body)
+	raw1.Resp.Body.Close()
+	raw2.Resp.Body.Close()
+	assert.Equal(t, 0, c.Stats()[host].Active)
+
+	resp := c.GET(ts.URL)
+	assert.Nil(t, resp.Err)
+	stats := c.Stats()[host]
+	assert.Equal(t, 0, stats.Active)
+	assert.Equal(t, stats.Open, stats.Idle)
+	assert.True(t, stats.Open >= 1)
+
+	c.HttpClient.CloseIdleConnections()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, client.HostStats{}, c.Stats()[host])
+}
+
+func TestPoolStatsByProxy(t *testing.T) {
+	var hits int32
+	proxy := proxyServer(&hits)
+	defer proxy.Close()
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("target"))
+	}))
+	defer target.Close()
+
+	// the open and active connections are both counted by proxy
+	c := client.NewClient(client.WithProxy(proxy.URL))
+	raw := c.ReqRaw(http.MethodGet, target.URL, nil)
+	if raw.Err != nil {
+		panic(raw.Err)
+	}
+	stats := c.Stats()
+	assert.Equal(t, client.HostStats{Open: 1, Active: 1, Idle: 0}, stats[proxy.Listener.Addr().String()])
+	assert.Equal(t, 1, len(stats))
+	raw.Resp.Body.Close()
+}
+
+func TestClientLiteral(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	// the client is not built by NewClient , no stats
+	c := &client.Client{Opts: &client.ClientOptions{}, HttpClient: &http.Client{}}
+	resp := c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "ok", string(resp.Body))
+	assert.Equal(t, 0, len(c.Stats()))
+}
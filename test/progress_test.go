@@ -0,0 +1,211 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/curl"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+)
+
+type progressRecorder struct {
+	mu      sync.Mutex
+	reports []request.Progress
+}
+
+func (p *progressRecorder) record(progress request.Progress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reports = append(p.reports, progress)
+}
+
+func (p *progressRecorder) last() request.Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.reports) == 0 {
+		return request.Progress{}
+	}
+	return p.reports[len(p.reports)-1]
+}
+
+func TestUploadProgress(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 256*1024)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		w.Write([]byte(strconv.FormatInt(n, 10)))
+	}))
+	defer ts.Close()
+
+	rec := &progressRecorder{}
+	resp := client.NewClient().Send(
+		context.Background(),
+		http.MethodPost,
+		ts.URL,
+		request.WithBody(bytes.NewReader(content)),
+		request.WithUploadProgress(rec.record, time.Millisecond),
+	)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, strconv.Itoa(len(content)), resp.GetBodyString())
+	last := rec.last()
+	assert.True(t, last.Done)
+	assert.Equal(t, int64(len(content)), last.Transferred)
+	assert.Equal(t, int64(len(content)), last.Total)
+}
+
+func TestUploadProgressRetry(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	rec := &progressRecorder{}
+	resp := client.NewClient(client.WithRetry(client.RetryPolicy{MaxAttempts: 2, InitialInterval: time.Millisecond})).Send(
+		context.Background(),
+		http.MethodPost,
+		ts.URL,
+		request.WithBody("hello"),
+		request.WithUploadProgress(rec.record),
+	)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "ok", resp.GetBodyString())
+	done := 0
+	for _, p := range rec.reports {
+		if p.Done {
+			done++
+			assert.Equal(t, int64(5), p.Transferred)
+		}
+	}
+	assert.Equal(t, 2, done)
+}
+
+func TestDownloadProgress(t *testing.T) {
+	content := downloadContent()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write(content)
+	}))
+	defer ts.Close()
+
+	rec := &progressRecorder{}
+	resp := client.NewClient(client.WithDownloadProgress(rec.record, time.Millisecond)).GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, len(content), len(resp.Body))
+	last := rec.last()
+	assert.True(t, last.Done)
+	assert.Equal(t, int64(len(content)), last.Transferred)
+	assert.Equal(t, int64(len(content)), last.Total)
+	assert.True(t, last.BytesPerSecond > 0)
+}
+
+func TestPOSTBinaryBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Write(b)
+	}))
+	defer ts.Close()
+
+	resp := curl.POSTBinaryBody(ts.URL, bytes.NewReader([]byte{0, 1, 2}), time.Second)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, []byte{0, 1, 2}, resp.Body)
+}
+
+func TestUploadProgressSigned(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		w.Write([]byte(strconv.FormatInt(n, 10)))
+	}))
+	defer ts.Close()
+
+	rec := &progressRecorder{}
+	reportsAtSign := -1
+	signer := request.SignerFunc(func(r *request.Request) error {
+		if _, err := r.BodySHA256(); err != nil {
+			return err
+		}
+		rec.mu.Lock()
+		reportsAtSign = len(rec.reports)
+		rec.mu.Unlock()
+		return nil
+	})
+	// the io.Reader body is read by signer before sending
+	resp := client.NewClient(client.WithSigner(signer)).Send(
+		context.Background(),
+		http.MethodPost,
+		ts.URL,
+		request.WithBody(io.LimitReader(bytes.NewReader(make([]byte, 1000)), 1000)),
+		request.WithUploadProgress(rec.record),
+	)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "1000", resp.GetBodyString())
+	assert.Equal(t, 0, reportsAtSign)
+	last := rec.last()
+	assert.True(t, last.Done)
+	assert.Equal(t, int64(1000), last.Transferred)
+}
+
+func TestUploadProgressRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		w.Write([]byte(strconv.FormatInt(n, 10)))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	rec := &progressRecorder{}
+	resp := client.NewClient().Send(
+		context.Background(),
+		http.MethodPost,
+		ts.URL+"/old",
+		request.WithBody("hello"),
+		request.WithUploadProgress(rec.record),
+	)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "5", resp.GetBodyString())
+	// the body replayed by GetBody for redirect is reported too
+	done := 0
+	for _, p := range rec.reports {
+		if p.Done {
+			done++
+			assert.Equal(t, int64(5), p.Transferred)
+		}
+	}
+	assert.Equal(t, 2, done)
+}
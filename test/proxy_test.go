@@ -0,0 +1,175 @@
+package test
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+)
+
+// proxyServer is a http proxy support CONNECT , it require user:pass
+func proxyServer(hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")) {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		atomic.AddInt32(hits, 1)
+		if r.Method == http.MethodConnect {
+			upstream, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			go func() { io.Copy(upstream, conn); upstream.Close() }()
+			io.Copy(conn, upstream)
+			conn.Close()
+			return
+		}
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.Header().Set("Via", "test-proxy")
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+}
+
+// socks5Server is a minimal rfc1928 server with username/password auth
+func socks5Server(hits *int32) net.Listener {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				br := bufio.NewReader(conn)
+				head := make([]byte, 2)
+				io.ReadFull(br, head)
+				io.ReadFull(br, make([]byte, head[1])) // methods
+				conn.Write([]byte{5, 2})               // username/password
+				io.ReadFull(br, head)
+				user := make([]byte, head[1])
+				io.ReadFull(br, user)
+				plen, _ := br.ReadByte()
+				pass := make([]byte, plen)
+				io.ReadFull(br, pass)
+				if string(user) != "user" || string(pass) != "pass" {
+					conn.Write([]byte{1, 1})
+					return
+				}
+				conn.Write([]byte{1, 0})
+				req := make([]byte, 4)
+				io.ReadFull(br, req)
+				var host string
+				switch req[3] {
+				case 1:
+					ip := make([]byte, 4)
+					io.ReadFull(br, ip)
+					host = net.IP(ip).String()
+				case 3:
+					l, _ := br.ReadByte()
+					name := make([]byte, l)
+					io.ReadFull(br, name)
+					host = string(name)
+				}
+				portBytes := make([]byte, 2)
+				io.ReadFull(br, portBytes)
+				upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes)))))
+				if err != nil {
+					conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer upstream.Close()
+				atomic.AddInt32(hits, 1)
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(upstream, br)
+				io.Copy(conn, upstream)
+			}(conn)
+		}
+	}()
+	return ln
+}
+
+func TestHTTPProxy(t *testing.T) {
+	var hits int32
+	proxy := proxyServer(&hits)
+	defer proxy.Close()
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("target"))
+	}))
+	defer target.Close()
+	tlsTarget := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tls target"))
+	}))
+	defer tlsTarget.Close()
+
+	proxyURL := "http://user:pass@" + proxy.Listener.Addr().String()
+	c := client.NewClient(client.WithProxy(proxyURL))
+	resp := c.GET(target.URL)
+	assert.Equal(t, "target", string(resp.Body))
+	assert.Equal(t, "test-proxy", resp.Resp.Header.Get("Via"))
+
+	// https by CONNECT , the custom tls config does not drop the proxy
+	c = client.NewClient(client.WithInsecureSkipVerify(), client.WithProxy(proxyURL))
+	resp = c.GET(tlsTarget.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "tls target", string(resp.Body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	// bypass the proxy
+	c = client.NewClient(client.WithProxy(proxyURL), client.WithNoProxy("example.com", "127.0.0.0/8"))
+	resp = c.GET(target.URL)
+	assert.Equal(t, "", resp.Resp.Header.Get("Via"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	resp = client.NewClient(client.WithProxy("ftp://proxy")).GET(target.URL)
+	assert.NotNil(t, resp.Err)
+}
+
+func TestSOCKS5Proxy(t *testing.T) {
+	var hits int32
+	ln := socks5Server(&hits)
+	defer ln.Close()
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("target"))
+	}))
+	defer target.Close()
+
+	c := client.NewClient(client.WithProxy("socks5://user:pass@" + ln.Addr().String()))
+	resp := c.GET(target.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "target", string(resp.Body))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	// socks5h is sent as socks5 which resolve the host by proxy
+	c = client.NewClient(client.WithProxy("socks5h://user:pass@" + ln.Addr().String()))
+	assert.Equal(t, "socks5", c.Opts.Proxy.Scheme)
+	resp = c.GET(target.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "target", string(resp.Body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/request"
+)
+
+func TestHttpBuildQueryEscape(t *testing.T) {
+	q := request.HttpBuildQuery(map[string]string{"b": "x&y=z", "a": "中 文"}, true)
+	assert.Equal(t, "a=%E4%B8%AD+%E6%96%87&b=x%26y%3Dz", q)
+	values, err := url.ParseQuery(q)
+	if err != nil {
+		panic(err)
+	}
+	assert.Equal(t, "x&y=z", values.Get("b"))
+}
+
+func TestEmptyQuery(t *testing.T) {
+	r, err := request.NewReuqest(http.MethodGet, "https://api.x/login", request.WithQuery(nil))
+	if err != nil {
+		panic(err)
+	}
+	assert.Equal(t, "https://api.x/login", r.Opts.Url)
+
+	r, err = request.NewReuqest(
+		http.MethodGet,
+		"https://api.x/login?a=1",
+		request.WithQuery(map[string]string{"b": "2"}),
+		request.WithQueryValues(url.Values{"c": {"3", "4"}}),
+	)
+	if err != nil {
+		panic(err)
+	}
+	assert.Equal(t, "https://api.x/login?a=1&b=2&c=3&c=4", r.Opts.Url)
+	assert.Equal(t, "b=2&c=3&c=4", r.Opts.Query)
+}
+
+func TestQueryStruct(t *testing.T) {
+	type Page struct {
+		Page int `query:"page,omitempty"`
+		Size int `query:"size"`
+	}
+	limit := 10
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	v := struct {
+		Page
+		Tags    []string   `query:"tag"`
+		Since   time.Time  `query:"since"`
+		Limit   *int       `query:"limit"`
+		Cursor  *string    `query:"cursor"`
+		Until   *time.Time `query:"until,omitempty"`
+		Name    string     `query:"name,omitempty"`
+		Active  bool
+		Ignored string `query:"-"`
+		private string
+	}{
+		Page:    Page{Size: 20},
+		Tags:    []string{"a b", "c&d"},
+		Since:   since,
+		Limit:   &limit,
+		Active:  true,
+		Ignored: "x",
+	}
+	values, err := request.EncodeQuery(&v)
+	if err != nil {
+		panic(err)
+	}
+	assert.Equal(t, url.Values{
+		"size":   {"20"},
+		"tag":    {"a b", "c&d"},
+		"since":  {"2024-01-02T03:04:05Z"},
+		"limit":  {"10"},
+		"Active": {"true"},
+	}, values)
+
+	r, err := request.NewReuqest(http.MethodGet, "https://api.x/items", request.WithQueryStruct(Page{Page: 2, Size: 5}))
+	if err != nil {
+		panic(err)
+	}
+	assert.Equal(t, "https://api.x/items?page=2&size=5", r.Opts.Url)
+
+	_, err = request.EncodeQuery(struct {
+		M map[string]string `query:"m"`
+	}{M: map[string]string{}})
+	assert.True(t, errors.Is(err, config.QueryEncodeError))
+	_, err = request.NewReuqest(http.MethodGet, "https://api.x/items", request.WithQueryStruct("x"))
+	assert.True(t, errors.Is(err, config.QueryEncodeError))
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+)
+
+func TestRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	c := client.NewClient(client.WithRateLimit(20, 2))
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		resp := c.GET(ts.URL)
+		if resp.Err != nil {
+			panic(resp.Err)
+		}
+	}
+	// 2 burst tokens , the other 2 wait 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestHostRateLimitCtx(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	c := client.NewClient(client.WithHostRateLimit(u.Host, 0.1, 1))
+	resp := c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	resp = c.GETCtx(ctx, ts.URL)
+	assert.True(t, errors.Is(resp.Err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRateLimitRetryAfterPause(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(client.WithRateLimit(1000, 10))
+	resp := c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, resp.Resp.StatusCode)
+	start := time.Now()
+	resp = c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+}
+
+func TestRateLimitReleaseHostToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	c := client.NewClient(client.WithRateLimit(10, 1), client.WithHostRateLimit(u.Host, 0.1, 2))
+	resp := c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	// canceled while waiting the global token , the host token is given back
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		resp = c.GETCtx(ctx, ts.URL)
+		cancel()
+		assert.True(t, errors.Is(resp.Err, context.DeadlineExceeded))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp = c.GETCtx(ctx, ts.URL)
+	assert.Nil(t, resp.Err)
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+)
+
+func TestRetryReplayBody(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(
+		client.WithRetry(client.RetryPolicy{
+			MaxAttempts:     3,
+			InitialInterval: time.Millisecond,
+		}),
+	)
+	resp := c.POST(ts.URL, jsonbody)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+	assert.Equal(t, string(jsonbody), string(resp.Body))
+}
+
+func TestRetryAfterAndMaxAttempts(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(
+		client.WithRetry(client.RetryPolicy{
+			MaxAttempts:     2,
+			InitialInterval: time.Hour, // Retry-After must win
+		}),
+	)
+	resp := c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+	assert.Equal(t, http.StatusTooManyRequests, resp.Resp.StatusCode)
+}
+
+func TestRetrySkipStatusCode(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(client.WithRetry())
+	resp := c.GET(ts.URL)
+	assert.Nil(t, resp.Err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+)
+
+func TestSigV4Vector(t *testing.T) {
+	// example of aws document "Signature Version 4 signing process"
+	r, err := request.NewReuqest(
+		http.MethodGet,
+		"https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+		request.WithContentType("application/x-www-form-urlencoded; charset=utf-8"),
+	)
+	if err != nil {
+		panic(err)
+	}
+	signer := &request.SigV4Signer{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "iam",
+		Now: func() time.Time {
+			return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+		},
+	}
+	if err := signer.Sign(r); err != nil {
+		panic(err)
+	}
+	assert.Equal(t,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		r.HttpReq.Header.Get("Authorization"),
+	)
+}
+
+func TestSigV4QueryOrder(t *testing.T) {
+	// get-vanilla-query-order-* of aws "Signature Version 4 test suite" , the last one is prefix keys
+	// which is not in the suite , its signature is computed by an independent implementation of the suite
+	for _, c := range []struct {
+		query     string
+		canonical string
+		signature string
+	}{
+		{"Param2=value2&Param1=value1", "Param1=value1&Param2=value2", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{"Param1=value2&Param1=value1", "Param1=value1&Param1=value2", "5772eed61e12b33fae39ee5e7012498b51d56abc0abb7c60486157bd471c4694"},
+		{"a_b=4&a.b=3&a-b=1&a=2", "a=2&a-b=1&a.b=3&a_b=4", "89bc78cad218ef04265d3d2fe5fff8df32497e25c8621271f19ff491f20bf9c6"},
+	} {
+		r, err := request.NewReuqest(http.MethodGet, "https://example.amazonaws.com/?"+c.query)
+		if err != nil {
+			panic(err)
+		}
+		r.HttpReq.Header.Del("Content-Type")
+		assert.Equal(t, c.canonical, request.CanonicalQuery(r.HttpReq.URL))
+		signer := &request.SigV4Signer{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			Region:          "us-east-1",
+			Service:         "service",
+			Now: func() time.Time {
+				return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+			},
+		}
+		if err := signer.Sign(r); err != nil {
+			panic(err)
+		}
+		assert.Equal(t,
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature="+c.signature,
+			r.HttpReq.Header.Get("Authorization"),
+		)
+	}
+}
+
+func TestSigV4S3StandIn(t *testing.T) {
+	verifier := &request.SigV4Signer{AccessKeyID: "minio", SecretAccessKey: "minio123", Region: "us-east-1", Service: "s3"}
+	// s3 stand-in sign the received request again and compare the signature
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		amzDate, _ := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+		v := *verifier
+		v.Now = func() time.Time { return amzDate }
+		u := *r.URL
+		u.Scheme, u.Host = "http", r.Host
+		again, _ := http.NewRequest(r.Method, u.String(), strings.NewReader(string(body)))
+		again.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+		if err := v.Sign(&request.Request{Opts: &request.ReqOptions{}, HttpReq: again}); err != nil {
+			panic(err)
+		}
+		if again.Header.Get("Authorization") != r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(client.WithSigner(verifier), client.WithRetry(client.RetryPolicy{InitialInterval: time.Millisecond}))
+	resp := c.PUT(ts.URL+"/bucket/some key.txt", "object content", map[string]string{"x-id": "PutObject"})
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+	assert.Equal(t, "object content", string(resp.Body))
+}
+
+func TestHMACSigner(t *testing.T) {
+	secret := []byte("secret")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodyHash := sha256.Sum256(body)
+		canonical := strings.Join([]string{
+			r.Method,
+			r.URL.EscapedPath(),
+			"a=1&b=2",
+			"host:" + r.Host + "\n" + "x-app:demo\n",
+			"host;x-app",
+			r.Header.Get("X-Timestamp"),
+			r.Header.Get("X-Nonce"),
+			hex.EncodeToString(bodyHash[:]),
+		}, "\n")
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(canonical))
+		expect := "HMAC-SHA256 KeyId=app, SignedHeaders=host;x-app, Signature=" + hex.EncodeToString(mac.Sum(nil))
+		if r.Header.Get("X-Signature") != expect {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	signer := &request.HMACSigner{KeyID: "app", Secret: secret, SignatureHeader: "X-Signature", SignedHeaders: []string{"X-App"}}
+	c := client.NewClient()
+	resp := c.Send(context.Background(), http.MethodPost, ts.URL+"/orders",
+		request.WithBody(jsonbody),
+		request.WithQuery(map[string]string{"b": "2", "a": "1"}),
+		request.WithHeader(map[string]string{"X-App": "demo"}),
+		request.WithSigner(signer),
+	)
+	assert.Equal(t, http.StatusOK, resp.Resp.StatusCode)
+	assert.Equal(t, string(jsonbody), string(resp.Body))
+}
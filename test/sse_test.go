@@ -0,0 +1,201 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/request"
+)
+
+func TestSSE(t *testing.T) {
+	var mu sync.Mutex
+	var lastIDs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastIDs = append(lastIDs, r.Header.Get("Last-Event-ID"))
+		n := len(lastIDs)
+		mu.Unlock()
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		switch n {
+		case 1:
+			io.WriteString(w, "\ufeff: comment\r\nretry: 10\r\n\r\nid: 1\r\ndata: hello\r\n\r\n")
+			w.(http.Flusher).Flush()
+			io.WriteString(w, "id: 2\nevent: update\ndata: line1\ndata:line2\n\nid: 3\ndata: incomplete")
+		case 2:
+			io.WriteString(w, "id: 3\rdata\r\r")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	stream := client.NewClient().SSE(context.Background(), ts.URL)
+	var events []client.Event
+	for e := range stream.Events() {
+		events = append(events, e)
+	}
+	assert.Nil(t, stream.Err())
+	assert.Equal(t, []client.Event{
+		{ID: "1", Event: "message", Data: "hello"},
+		{ID: "2", Event: "update", Data: "line1\nline2"},
+		{ID: "3", Event: "message", Data: ""},
+	}, events)
+	assert.Equal(t, []string{"", "2", "3"}, lastIDs)
+}
+
+func TestSSEClose(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	stream := client.NewClient(client.WithTimeOut(0)).SSE(context.Background(), ts.URL)
+	select {
+	case e := <-stream.Events():
+		assert.Equal(t, "first", e.Data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	assert.Nil(t, stream.Close())
+	_, ok := <-stream.Events()
+	assert.False(t, ok)
+}
+
+func TestSSEContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	stream := client.NewClient().SSE(ctx, ts.URL)
+	for range stream.Events() {
+	}
+	assert.True(t, errors.Is(stream.Err(), context.DeadlineExceeded))
+}
+
+func TestSSEContentType(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	stream := client.NewClient().SSE(context.Background(), ts.URL)
+	for range stream.Events() {
+	}
+	assert.True(t, errors.Is(stream.Err(), config.EventStreamError))
+}
+
+func TestSSERequestError(t *testing.T) {
+	var n int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// the request can not be built , it is not reconnected
+	stream := client.NewClient().SSE(ctx, ts.URL+"/{id}", request.WithPathParams(map[string]string{}))
+	for range stream.Events() {
+	}
+	assert.True(t, errors.Is(stream.Err(), config.PathParamError))
+
+	signErr := errors.New("sign failed")
+	stream = client.NewClient(client.WithSigner(request.SignerFunc(func(r *request.Request) error {
+		return signErr
+	}))).SSE(ctx, ts.URL)
+	for range stream.Events() {
+	}
+	assert.True(t, errors.Is(stream.Err(), signErr))
+	assert.Nil(t, ctx.Err())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&n))
+}
+
+func TestSSEIgnoreClientTimeout(t *testing.T) {
+	var n int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1) > 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "retry: 10\ndata: first\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(300 * time.Millisecond)
+		io.WriteString(w, "data: second\n\n")
+	}))
+	defer ts.Close()
+
+	c := client.NewClient(client.WithTimeOut(100 * time.Millisecond))
+	stream := c.SSE(context.Background(), ts.URL)
+	var data []string
+	for e := range stream.Events() {
+		data = append(data, e.Data)
+	}
+	assert.Nil(t, stream.Err())
+	assert.Equal(t, []string{"first", "second"}, data)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+	assert.Equal(t, 100*time.Millisecond, c.HttpClient.Timeout)
+}
+
+func TestSSEDebug(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	// the debug mode does not read the endless stream
+	stream := client.NewClient(client.WithDebug(), client.WithTimeOut(0)).SSE(context.Background(), ts.URL)
+	defer stream.Close()
+	select {
+	case e := <-stream.Events():
+		assert.Equal(t, "first", e.Data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+}
+
+func TestDebugWithStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "{}\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp := client.NewClient(client.WithDebug()).SendRaw(ctx, http.MethodGet, ts.URL, request.WithStream())
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	defer resp.Resp.Body.Close()
+	line := make([]byte, 3)
+	_, err := io.ReadFull(resp.Resp.Body, line)
+	assert.Nil(t, err)
+	assert.Equal(t, "{}\n", string(line))
+}
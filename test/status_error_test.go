@@ -0,0 +1,45 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/config"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/response"
+)
+
+func TestRaiseForStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Reason", "boom")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(strings.Repeat("e", config.DefaultErrorBodySize*2)))
+	}))
+	defer ts.Close()
+
+	// opt-in , default client does not raise
+	resp := client.NewClient().GET(ts.URL)
+	assert.Nil(t, resp.Err)
+	assert.True(t, errors.Is(resp.Raise(), config.StatusCodeError))
+
+	c := client.NewClient(client.WithRaiseForStatus())
+	for _, resp := range []response.Response{
+		c.POST(ts.URL, jsonbody),
+		c.ReqRaw(http.MethodDelete, ts.URL, nil),
+	} {
+		assert.True(t, errors.Is(resp.Err, config.StatusCodeError))
+		var statusErr *response.StatusError
+		if !errors.As(resp.Err, &statusErr) {
+			t.Fatalf("expect *response.StatusError but got %T", resp.Err)
+		}
+		assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+		assert.Equal(t, ts.URL, statusErr.URL)
+		assert.NotEmpty(t, statusErr.Method)
+		assert.Equal(t, "boom", statusErr.Header.Get("X-Reason"))
+		assert.Equal(t, config.DefaultErrorBodySize, len(statusErr.Body))
+	}
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+	"github.com/zengzhengrong/request/response"
+)
+
+type streamItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func streamResponse(body string) (response.Response, *closeRecorder) {
+	rc := &closeRecorder{Reader: strings.NewReader(body)}
+	return response.Response{Resp: &http.Response{StatusCode: 200, Body: rc}}, rc
+}
+
+func TestStream(t *testing.T) {
+	expected := []streamItem{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "c"}}
+	for _, body := range []string{
+		"{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\r\n\n{\"id\":3,\"name\":\"c\"}\n",
+		" [ {\"id\":1,\"name\":\"a\"},\n {\"id\":2,\"name\":\"b\"}, {\"id\":3,\"name\":\"c\"} ]\n",
+	} {
+		resp, rc := streamResponse(body)
+		s := response.Stream[streamItem](&resp)
+		var items []streamItem
+		for s.Next() {
+			items = append(items, s.Value())
+		}
+		assert.Nil(t, s.Err())
+		assert.Equal(t, expected, items)
+		assert.True(t, rc.closed)
+	}
+
+	for _, body := range []string{"", " \n", "[]"} {
+		resp, _ := streamResponse(body)
+		s := response.Stream[streamItem](&resp)
+		assert.False(t, s.Next())
+		assert.Nil(t, s.Err())
+	}
+
+	for _, body := range []string{"[{\"id\":1}", "[{\"id\":1}] {}", "{\"id\":\"x\"}"} {
+		resp, _ := streamResponse(body)
+		s := response.Stream[streamItem](&resp)
+		for s.Next() {
+		}
+		assert.NotNil(t, s.Err(), body)
+	}
+}
+
+func TestEachJSON(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 0; i < 1000; i++ {
+			w.Write([]byte(`{"id":1,"name":"item"}` + "\n"))
+		}
+	}))
+	defer ts.Close()
+
+	resp := client.NewClient().SendRaw(context.Background(), http.MethodGet, ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	count := 0
+	err := resp.EachJSON(func(raw []byte) error {
+		count++
+		assert.Equal(t, []byte(`{"id":1,"name":"item"}`), raw)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	assert.Equal(t, 1000, count)
+
+	// stop by the error of fn
+	stop := errors.New("stop")
+	resp, rc := streamResponse(`[1,2,3]`)
+	var raws [][]byte
+	err = resp.EachJSON(func(raw []byte) error {
+		raws = append(raws, raw)
+		if bytes.Equal(raw, []byte("2")) {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, [][]byte{[]byte("1"), []byte("2")}, raws)
+	assert.True(t, rc.closed)
+
+	// the body which is already read
+	resp = response.Response{Body: []byte("1\n2\n")}
+	count = 0
+	assert.Nil(t, resp.EachJSON(func(raw []byte) error { count++; return nil }))
+	assert.Equal(t, 2, count)
+}
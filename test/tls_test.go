@@ -0,0 +1,133 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zengzhengrong/request/opts/client"
+)
+
+// issueCert is sign a certificate by the ca , it is a self signed ca if ca is nil
+func issueCert(cn string, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if ca == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+		ca, caKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		panic(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return cert, key, certPEM, keyPEM
+}
+
+func mtlsServer(ca *x509.Certificate) *httptest.Server {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	ts.StartTLS()
+	return ts
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca, caKey, _, _ := issueCert("test ca", nil, nil)
+	_, _, certPEM, keyPEM := issueCert("client-a", ca, caKey)
+	ts := mtlsServer(ca)
+	defer ts.Close()
+
+	dir := t.TempDir()
+	serverCA := filepath.Join(dir, "server-ca.pem")
+	os.WriteFile(serverCA, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600)
+
+	// the transport set before is cloned , not overwritten
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.MaxIdleConnsPerHost = 7
+	c := client.NewClient(
+		client.WithTransport(base),
+		client.WithRootCAs(serverCA),
+		client.WithClientCert(certPEM, keyPEM),
+		client.WithTLSMinVersion(tls.VersionTLS12),
+	)
+	resp := c.GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "client-a", string(resp.Body))
+	transport := c.HttpClient.Transport.(*http.Transport)
+	assert.Equal(t, 7, transport.MaxIdleConnsPerHost)
+	assert.NotNil(t, transport.Proxy)
+	assert.True(t, base.TLSClientConfig == nil || len(base.TLSClientConfig.Certificates) == 0)
+
+	// the deprecated option is still a base tls config
+	resp = client.NewClient(
+		&client.TLSClientConfigOption{Config: tls.Config{InsecureSkipVerify: true}},
+		client.WithClientCert(certPEM, keyPEM),
+	).GET(ts.URL)
+	if resp.Err != nil {
+		panic(resp.Err)
+	}
+	assert.Equal(t, "client-a", string(resp.Body))
+
+	// without client cert
+	resp = client.NewClient(client.WithRootCAs(serverCA)).GET(ts.URL)
+	assert.NotNil(t, resp.Err)
+	// invalid ca file
+	resp = client.NewClient(client.WithRootCAs(filepath.Join(dir, "missing.pem"))).GET(ts.URL)
+	assert.NotNil(t, resp.Err)
+}
+
+func TestClientCertReload(t *testing.T) {
+	ca, caKey, _, _ := issueCert("test ca", nil, nil)
+	_, _, certPEM, keyPEM := issueCert("client-a", ca, caKey)
+	ts := mtlsServer(ca)
+	defer ts.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	os.WriteFile(certFile, certPEM, 0600)
+	os.WriteFile(keyFile, keyPEM, 0600)
+
+	c := client.NewClient(client.WithInsecureSkipVerify(), client.WithClientCertFile(certFile, keyFile))
+	resp := c.GET(ts.URL)
+	assert.Equal(t, "client-a", string(resp.Body))
+
+	// renew the cert , the new connection use it
+	_, _, certPEM, keyPEM = issueCert("client-b", ca, caKey)
+	os.WriteFile(certFile, certPEM, 0600)
+	os.WriteFile(keyFile, keyPEM, 0600)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	c.HttpClient.CloseIdleConnections()
+	resp = c.GET(ts.URL)
+	assert.Equal(t, "client-b", string(resp.Body))
+}